@@ -0,0 +1,179 @@
+package wwgo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type AlertWriterOpt struct {
+	// Optional, the log field to take the alert category from (default
+	// "alertCategory").
+	CategoryField string
+	// Optional, the category when the field is not set (default "api_error").
+	DefaultCategory string
+	// Optional, identical alerts are only sent once within this window (default
+	// 10 minutes).
+	DedupWindow time.Duration
+	// Optional, the maximum alerts per category per minute (default 10).
+	RateLimit int
+	// Optional, the number of alerts that can be waiting to send before they
+	// are dropped (default 100).
+	QueueSize int
+}
+
+// AlertWriter is a zerolog.LevelWriter that forwards log events at or above a
+// level to a CategoryAlerter, i.e.
+// logger.Output(zerolog.MultiLevelWriter(os.Stderr, alertWriter)).
+// Alerts are sent in the background so logging never blocks.
+// NOTE: The alerter should not log to a logger that writes to this, or a
+// failing alerter will alert about itself.
+type AlertWriter struct {
+	alerter  CategoryAlerter
+	minLevel zerolog.Level
+	opt      AlertWriterOpt
+	queue    chan alertWriterItem
+	mut      sync.Mutex
+	// Keyed by category & message.
+	lastSent   map[string]time.Time
+	suppressed map[string]int
+	// Keyed by category.
+	recent map[string][]time.Time
+}
+
+type alertWriterItem struct {
+	category string
+	msg      string
+}
+
+func NewAlertWriter(ctx context.Context, alerter CategoryAlerter, minLevel zerolog.Level, opt AlertWriterOpt) *AlertWriter {
+	if opt.CategoryField == "" {
+		opt.CategoryField = "alertCategory"
+	}
+	if opt.DefaultCategory == "" {
+		opt.DefaultCategory = "api_error"
+	}
+	if opt.DedupWindow == 0 {
+		opt.DedupWindow = 10 * time.Minute
+	}
+	if opt.RateLimit == 0 {
+		opt.RateLimit = 10
+	}
+	if opt.QueueSize == 0 {
+		opt.QueueSize = 100
+	}
+	w := &AlertWriter{
+		alerter:    alerter,
+		minLevel:   minLevel,
+		opt:        opt,
+		queue:      make(chan alertWriterItem, opt.QueueSize),
+		lastSent:   map[string]time.Time{},
+		suppressed: map[string]int{},
+		recent:     map[string][]time.Time{},
+	}
+	go w.send(ctx)
+	return w
+}
+
+func (w *AlertWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (w *AlertWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < w.minLevel || level == zerolog.NoLevel || level == zerolog.Disabled {
+		return len(p), nil
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(p, &entry); err != nil {
+		return len(p), nil
+	}
+	category, _ := entry[w.opt.CategoryField].(string)
+	if category == "" {
+		category = w.opt.DefaultCategory
+	}
+	msg, _ := entry[zerolog.MessageFieldName].(string)
+	if errStr, ok := entry[zerolog.ErrorFieldName].(string); ok && errStr != "" {
+		msg = ArrayFilterAndJoinStr([]string{msg, errStr}, ": ")
+	}
+	if msg == "" {
+		return len(p), nil
+	}
+
+	for _, item := range w.allow(level, category, msg, time.Now()) {
+		select {
+		case w.queue <- item:
+		default:
+			_, _ = fmt.Fprintf(os.Stderr, "AlertWriter queue is full, dropped alert: %s\n", item.msg)
+		}
+	}
+	return len(p), nil
+}
+
+// allow applies the dedup & rate limit, returning the alerts to send. The
+// message is sent with the number of suppressed duplicates if there were any,
+// along with a summary of any suppressed duplicates that have expired.
+func (w *AlertWriter) allow(level zerolog.Level, category string, msg string, now time.Time) []alertWriterItem {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	// Dedup.
+	key := category + "\x00" + msg
+	if last, ok := w.lastSent[key]; ok && now.Sub(last) < w.opt.DedupWindow {
+		w.suppressed[key]++
+		return nil
+	}
+
+	// Rate limit.
+	recent := FilterSlice(w.recent[category], func(t time.Time) bool {
+		return now.Sub(t) < time.Minute
+	})
+	if len(recent) >= w.opt.RateLimit {
+		w.recent[category] = recent
+		return nil
+	}
+	w.recent[category] = append(recent, now)
+
+	// Prune old dedup entries so the maps don't grow forever, reporting any
+	// repeats that were suppressed since they were last sent.
+	var res []alertWriterItem
+	for k, last := range w.lastSent {
+		if now.Sub(last) < w.opt.DedupWindow || k == key {
+			continue
+		}
+		if n := w.suppressed[k]; n != 0 {
+			prunedCategory, prunedMsg, _ := strings.Cut(k, "\x00")
+			res = append(res, alertWriterItem{
+				category: prunedCategory,
+				msg:      fmt.Sprintf("%s (suppressed %d %s)", prunedMsg, n, Plural(n, "repeat", "repeats")),
+			})
+		}
+		delete(w.lastSent, k)
+		delete(w.suppressed, k)
+	}
+
+	if n := w.suppressed[key]; n != 0 {
+		msg = fmt.Sprintf("%s (repeated %d more %s)", msg, n, Plural(n, "time", "times"))
+		delete(w.suppressed, key)
+	}
+	w.lastSent[key] = now
+	return append(res, alertWriterItem{category: category, msg: fmt.Sprintf("[%s] %s", level, msg)})
+}
+
+func (w *AlertWriter) send(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item := <-w.queue:
+			if err := w.alerter.SendAlert(ctx, item.category, item.msg); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "AlertWriter failed to send alert: %s\n", err)
+			}
+		}
+	}
+}
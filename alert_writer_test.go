@@ -0,0 +1,134 @@
+package wwgo
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testAlert struct {
+	category string
+	msg      string
+}
+
+type testCategoryAlerter struct {
+	alerts chan testAlert
+}
+
+func (a *testCategoryAlerter) SendAlert(ctx context.Context, category string, msg string) error {
+	a.alerts <- testAlert{category: category, msg: msg}
+	return nil
+}
+
+// newTestAlertWriter does not send, use allow directly.
+func newTestAlertWriter(opt AlertWriterOpt) *AlertWriter {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return NewAlertWriter(ctx, &testCategoryAlerter{}, zerolog.ErrorLevel, opt)
+}
+
+func alertMessages(items []alertWriterItem) []string {
+	return MapSlice(items, func(item alertWriterItem) string {
+		return item.category + ": " + item.msg
+	})
+}
+
+func TestAlertWriterDedup(t *testing.T) {
+	w := newTestAlertWriter(AlertWriterOpt{DedupWindow: 10 * time.Minute})
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		at       time.Duration
+		msg      string
+		expected []string
+	}{
+		{"first", 0, "a", []string{"api_error: [error] a"}},
+		{"repeat within the window", time.Minute, "a", []string{}},
+		{"another repeat", 2 * time.Minute, "a", []string{}},
+		{"another message", 3 * time.Minute, "b", []string{"api_error: [error] b"}},
+		{"after the window", 11 * time.Minute, "a", []string{"api_error: [error] a (repeated 2 more times)"}},
+		{"repeat within the new window", 12 * time.Minute, "a", []string{}},
+	}
+	for _, test := range tests {
+		res := alertMessages(w.allow(zerolog.ErrorLevel, "api_error", test.msg, now.Add(test.at)))
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, res)
+		}
+	}
+}
+
+func TestAlertWriterDedupPruneReportsSuppressed(t *testing.T) {
+	w := newTestAlertWriter(AlertWriterOpt{DedupWindow: 10 * time.Minute})
+	now := time.Now()
+
+	w.allow(zerolog.ErrorLevel, "payments", "a", now)
+	w.allow(zerolog.ErrorLevel, "payments", "a", now.Add(time.Minute))
+	w.allow(zerolog.ErrorLevel, "api_error", "c", now)
+
+	// "a" expires when "b" is sent, so its suppressed repeat must be reported
+	// before it is forgotten, "c" had no repeats.
+	res := alertMessages(w.allow(zerolog.ErrorLevel, "api_error", "b", now.Add(11*time.Minute)))
+	expected := []string{"payments: a (suppressed 1 repeat)", "api_error: [error] b"}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+	if len(w.lastSent) != 1 || len(w.suppressed) != 0 {
+		t.Fatalf("expected the expired entries to be pruned, got %v %v", w.lastSent, w.suppressed)
+	}
+}
+
+func TestAlertWriterRateLimit(t *testing.T) {
+	w := newTestAlertWriter(AlertWriterOpt{RateLimit: 2})
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		at       time.Duration
+		category string
+		msg      string
+		allowed  bool
+	}{
+		{"first", 0, "api_error", "a", true},
+		{"second", time.Second, "api_error", "b", true},
+		{"over the limit", 2 * time.Second, "api_error", "c", false},
+		{"another category", 3 * time.Second, "payments", "d", true},
+		{"still over the limit", 59 * time.Second, "api_error", "e", false},
+		{"first has expired", time.Minute, "api_error", "f", true},
+		{"over the limit again", time.Minute + time.Second/2, "api_error", "g", false},
+	}
+	for _, test := range tests {
+		res := w.allow(zerolog.ErrorLevel, test.category, test.msg, now.Add(test.at))
+		if allowed := len(res) != 0; allowed != test.allowed {
+			t.Errorf("%s: expected allowed to be %v, got %v", test.name, test.allowed, allowed)
+		}
+	}
+}
+
+func TestAlertWriterSends(t *testing.T) {
+	alerter := &testCategoryAlerter{alerts: make(chan testAlert, 10)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := NewAlertWriter(ctx, alerter, zerolog.ErrorLevel, AlertWriterOpt{})
+	log := zerolog.New(w)
+
+	log.Warn().Msg("ignored")
+	log.Error().Str("alertCategory", "payments").Msg("failed")
+
+	select {
+	case alert := <-alerter.alerts:
+		expected := testAlert{category: "payments", msg: "[error] failed"}
+		if alert != expected {
+			t.Fatalf("expected %v, got %v", expected, alert)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected an alert")
+	}
+	select {
+	case alert := <-alerter.alerts:
+		t.Fatalf("expected one alert, got %v", alert)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
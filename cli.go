@@ -1,54 +1,34 @@
 package wwgo
 
 import (
-	"bufio"
-	"fmt"
 	"github.com/pkg/errors"
-	"golang.org/x/crypto/ssh/terminal"
-	"os"
-	"strings"
 )
 
-func CliAsk(question string, defaultAnswer string) string {
-	fmt.Printf(question)
-	if defaultAnswer != "" {
-		fmt.Printf(" [%s]", defaultAnswer)
-	}
-	fmt.Printf(": ")
+// The Cli* functions prompt on stdin/stdout, see Prompter for more options.
 
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
-	return strings.TrimSpace(input)
+func CliAsk(question string, defaultAnswer string) string {
+	answer, _ := stdPrompter().Ask(Question{Message: question, Default: defaultAnswer})
+	return answer
 }
 
 func CliAskRequired(question string, defaultAnswer string) string {
-	for {
-		answer := CliAsk(question, defaultAnswer)
-		if answer != "" {
-			return answer
-		} else if defaultAnswer != "" {
-			return defaultAnswer
-		}
+	answer, err := stdPrompter().Ask(Question{Message: question, Default: defaultAnswer, Required: true})
+	if err != nil {
+		panic(err)
 	}
+	return answer
 }
 
 func CliAskPassword(question string) string {
-	fmt.Printf("%s: ", question)
-	password, err := terminal.ReadPassword(0)
+	password, err := stdPrompter().Password(Question{Message: question}, false)
 	if err != nil {
 		panic(errors.Wrapf(err, "failed to read password"))
 	}
-	fmt.Println()
-	return string(password)
+	return password
 }
 
+// CliConfirm returns false if there is no answer i.e. stdin is closed.
 func CliConfirm(question string) bool {
-	reader := bufio.NewReader(os.Stdin)
-	answer := ""
-	for answer != "y" && answer != "n" {
-		fmt.Print(question + " [y/n] ")
-		input, _ := reader.ReadByte()
-		answer = strings.ToLower(string(input))
-	}
-	return answer == "y"
+	yes, _ := stdPrompter().Confirm(Question{Message: question})
+	return yes
 }
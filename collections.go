@@ -0,0 +1,236 @@
+package wwgo
+
+// Generic collection helpers, see the package documentation for migrating
+// from the deprecated typed helpers in util.go.
+
+// Seq is a lazy sequence, compatible with iter.Seq.
+type Seq[T any] func(yield func(T) bool)
+
+// SliceToSet returns a set of the values for fast lookups.
+func SliceToSet[T comparable](s []T) map[T]struct{} {
+	set := make(map[T]struct{}, len(s))
+	for _, v := range s {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+// IntersectSlice returns the unique values from a that are also in b, in the
+// order of a.
+func IntersectSlice[T comparable](a []T, b []T) []T {
+	bSet := SliceToSet(b)
+	res := make([]T, 0)
+	seen := make(map[T]struct{})
+	for _, v := range a {
+		if _, ok := bSet[v]; !ok {
+			continue
+		}
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		res = append(res, v)
+	}
+	return res
+}
+
+// UnionSlice returns the unique values from all the slices, in order.
+func UnionSlice[T comparable](slices ...[]T) []T {
+	res := make([]T, 0)
+	seen := make(map[T]struct{})
+	for _, s := range slices {
+		for _, v := range s {
+			if _, ok := seen[v]; ok {
+				continue
+			}
+			seen[v] = struct{}{}
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// UniqSlice returns the unique values, in order.
+func UniqSlice[T comparable](s []T) []T {
+	return UnionSlice(s)
+}
+
+// UniqSliceBy returns the values with a unique key, the first value wins.
+func UniqSliceBy[T any, K comparable](s []T, key func(T) K) []T {
+	res := make([]T, 0)
+	seen := make(map[K]struct{})
+	for _, v := range s {
+		k := key(v)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		res = append(res, v)
+	}
+	return res
+}
+
+// GroupSliceBy groups the values by key, preserving order within each group.
+func GroupSliceBy[T any, K comparable](s []T, key func(T) K) map[K][]T {
+	res := make(map[K][]T)
+	for _, v := range s {
+		k := key(v)
+		res[k] = append(res[k], v)
+	}
+	return res
+}
+
+// KeySliceBy returns a lookup map of the values by key, the last value wins.
+func KeySliceBy[T any, K comparable](s []T, key func(T) K) map[K]T {
+	res := make(map[K]T, len(s))
+	for _, v := range s {
+		res[key(v)] = v
+	}
+	return res
+}
+
+// SliceToMap returns a map of the keys & values returned by f.
+func SliceToMap[T any, K comparable, V any](s []T, f func(T) (K, V)) map[K]V {
+	res := make(map[K]V, len(s))
+	for _, v := range s {
+		k, mv := f(v)
+		res[k] = mv
+	}
+	return res
+}
+
+// ChunkSlice splits s into slices of up to size values, the chunks share the
+// underlying array of s.
+func ChunkSlice[T any](s []T, size int) [][]T {
+	if size < 1 {
+		panic("ChunkSlice: size must be at least 1")
+	}
+	res := make([][]T, 0, (len(s)+size-1)/size)
+	for i := 0; i < len(s); i += size {
+		res = append(res, s[i:min(i+size, len(s)):min(i+size, len(s))])
+	}
+	return res
+}
+
+// PartitionSlice splits s into the values that pass f and those that do not.
+func PartitionSlice[T any](s []T, f func(T) bool) ([]T, []T) {
+	pass := make([]T, 0)
+	fail := make([]T, 0)
+	for _, v := range s {
+		if f(v) {
+			pass = append(pass, v)
+		} else {
+			fail = append(fail, v)
+		}
+	}
+	return pass, fail
+}
+
+// SliceSeq returns a Seq of the values.
+func SliceSeq[T any](s []T) Seq[T] {
+	return func(yield func(T) bool) {
+		for _, v := range s {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// CollectSeq returns the values of the Seq as a slice.
+func CollectSeq[T any](seq Seq[T]) []T {
+	res := make([]T, 0)
+	seq(func(v T) bool {
+		res = append(res, v)
+		return true
+	})
+	return res
+}
+
+// MapSeq lazily maps the values by f.
+func MapSeq[IN any, OUT any](seq Seq[IN], f func(IN) OUT) Seq[OUT] {
+	return func(yield func(OUT) bool) {
+		seq(func(v IN) bool {
+			return yield(f(v))
+		})
+	}
+}
+
+// FilterSeq lazily filters the values by f.
+func FilterSeq[T any](seq Seq[T], f func(T) bool) Seq[T] {
+	return func(yield func(T) bool) {
+		seq(func(v T) bool {
+			if !f(v) {
+				return true
+			}
+			return yield(v)
+		})
+	}
+}
+
+// UniqSeq lazily filters out duplicate values.
+func UniqSeq[T comparable](seq Seq[T]) Seq[T] {
+	return func(yield func(T) bool) {
+		seen := make(map[T]struct{})
+		seq(func(v T) bool {
+			if _, ok := seen[v]; ok {
+				return true
+			}
+			seen[v] = struct{}{}
+			return yield(v)
+		})
+	}
+}
+
+// DiffSeq lazily filters out the values that are in exclude.
+func DiffSeq[T comparable](seq Seq[T], exclude []T) Seq[T] {
+	return func(yield func(T) bool) {
+		excludeSet := SliceToSet(exclude)
+		seq(func(v T) bool {
+			if _, ok := excludeSet[v]; ok {
+				return true
+			}
+			return yield(v)
+		})
+	}
+}
+
+// ChunkSeq lazily groups the values into slices of up to size values.
+func ChunkSeq[T any](seq Seq[T], size int) Seq[[]T] {
+	if size < 1 {
+		panic("ChunkSeq: size must be at least 1")
+	}
+	return func(yield func([]T) bool) {
+		chunk := make([]T, 0, size)
+		stopped := false
+		seq(func(v T) bool {
+			chunk = append(chunk, v)
+			if len(chunk) < size {
+				return true
+			}
+			if !yield(chunk) {
+				stopped = true
+				return false
+			}
+			chunk = make([]T, 0, size)
+			return true
+		})
+		if !stopped && len(chunk) != 0 {
+			yield(chunk)
+		}
+	}
+}
+
+// TakeSeq lazily returns up to n values.
+func TakeSeq[T any](seq Seq[T], n int) Seq[T] {
+	return func(yield func(T) bool) {
+		if n <= 0 {
+			return
+		}
+		taken := 0
+		seq(func(v T) bool {
+			taken++
+			return yield(v) && taken < n
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package wwgo
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+const benchmarkSliceLen = 10000
+
+func benchmarkInts(n int, offset int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i + offset
+	}
+	return s
+}
+
+// naiveDiffSlice is the O(n*m) implementation DiffSlice replaced, for
+// comparison.
+func naiveDiffSlice[T comparable](a []T, b []T) []T {
+	diff := make([]T, 0)
+	for _, v := range a {
+		if !SliceIncludes(b, v) {
+			diff = append(diff, v)
+		}
+	}
+	return diff
+}
+
+func BenchmarkDiffSlice(b *testing.B) {
+	x := benchmarkInts(benchmarkSliceLen, 0)
+	y := benchmarkInts(benchmarkSliceLen, benchmarkSliceLen/2)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DiffSlice(x, y)
+	}
+}
+
+func BenchmarkDiffSliceNaive(b *testing.B) {
+	x := benchmarkInts(benchmarkSliceLen, 0)
+	y := benchmarkInts(benchmarkSliceLen, benchmarkSliceLen/2)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		naiveDiffSlice(x, y)
+	}
+}
+
+func BenchmarkIntersectSlice(b *testing.B) {
+	x := benchmarkInts(benchmarkSliceLen, 0)
+	y := benchmarkInts(benchmarkSliceLen, benchmarkSliceLen/2)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		IntersectSlice(x, y)
+	}
+}
+
+func BenchmarkUnionSlice(b *testing.B) {
+	x := benchmarkInts(benchmarkSliceLen, 0)
+	y := benchmarkInts(benchmarkSliceLen, benchmarkSliceLen/2)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		UnionSlice(x, y)
+	}
+}
+
+func BenchmarkUniqSlice(b *testing.B) {
+	s := append(benchmarkInts(benchmarkSliceLen, 0), benchmarkInts(benchmarkSliceLen, 0)...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		UniqSlice(s)
+	}
+}
+
+func BenchmarkGroupSliceBy(b *testing.B) {
+	s := benchmarkInts(benchmarkSliceLen, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		GroupSliceBy(s, func(v int) int { return v % 100 })
+	}
+}
+
+func BenchmarkKeySliceBy(b *testing.B) {
+	s := benchmarkInts(benchmarkSliceLen, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		KeySliceBy(s, strconv.Itoa)
+	}
+}
+
+func BenchmarkChunkSlice(b *testing.B) {
+	s := benchmarkInts(benchmarkSliceLen, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ChunkSlice(s, 100)
+	}
+}
+
+func BenchmarkPartitionSlice(b *testing.B) {
+	s := benchmarkInts(benchmarkSliceLen, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		PartitionSlice(s, func(v int) bool { return v%2 == 0 })
+	}
+}
+
+func BenchmarkSeq(b *testing.B) {
+	s := append(benchmarkInts(benchmarkSliceLen, 0), benchmarkInts(benchmarkSliceLen, 0)...)
+	exclude := benchmarkInts(benchmarkSliceLen/2, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		seq := UniqSeq(DiffSeq(FilterSeq(SliceSeq(s), func(v int) bool { return v%2 == 0 }), exclude))
+		CollectSeq(ChunkSeq(MapSeq(seq, strconv.Itoa), 100))
+	}
+}
+
+func BenchmarkTakeSeq(b *testing.B) {
+	s := benchmarkInts(benchmarkSliceLen, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		CollectSeq(TakeSeq(SliceSeq(s), 10))
+	}
+}
+
+// countSeq is a SliceSeq that counts how many values were pulled.
+func countSeq[T any](s []T, pulled *int) Seq[T] {
+	return func(yield func(T) bool) {
+		for _, v := range s {
+			*pulled++
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func TestDiffSlice(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        []int
+		b        []int
+		expected []int
+	}{
+		{"empty", nil, []int{1}, []int{}},
+		{"no overlap", []int{1, 2}, []int{3}, []int{1, 2}},
+		{"overlap", []int{1, 2, 3, 2}, []int{2}, []int{1, 3}},
+		{"keeps duplicates", []int{1, 1, 3}, []int{3}, []int{1, 1}},
+		{"all excluded", []int{1, 2}, []int{2, 1}, []int{}},
+	}
+	for _, test := range tests {
+		if res := DiffSlice(test.a, test.b); !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, res)
+		}
+	}
+}
+
+func TestIntersectSlice(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        []int
+		b        []int
+		expected []int
+	}{
+		{"empty", nil, []int{1}, []int{}},
+		{"no overlap", []int{1, 2}, []int{3}, []int{}},
+		{"order of a", []int{3, 1, 2}, []int{1, 2, 3}, []int{3, 1, 2}},
+		{"unique", []int{1, 2, 1, 2}, []int{2, 1}, []int{1, 2}},
+	}
+	for _, test := range tests {
+		if res := IntersectSlice(test.a, test.b); !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, res)
+		}
+	}
+}
+
+func TestUnionSlice(t *testing.T) {
+	tests := []struct {
+		name     string
+		slices   [][]int
+		expected []int
+	}{
+		{"none", nil, []int{}},
+		{"one", [][]int{{1, 2, 1}}, []int{1, 2}},
+		{"many", [][]int{{3, 1}, {1, 2}, nil, {2, 4}}, []int{3, 1, 2, 4}},
+	}
+	for _, test := range tests {
+		if res := UnionSlice(test.slices...); !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, res)
+		}
+	}
+}
+
+func TestUniqSliceBy(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        []string
+		expected []string
+	}{
+		{"empty", nil, []string{}},
+		{"first wins", []string{"a1", "b1", "a2", "c1", "b2"}, []string{"a1", "b1", "c1"}},
+		{"unique", []string{"a1", "b1"}, []string{"a1", "b1"}},
+	}
+	for _, test := range tests {
+		res := UniqSliceBy(test.s, func(v string) byte { return v[0] })
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, res)
+		}
+	}
+}
+
+func TestGroupSliceBy(t *testing.T) {
+	res := GroupSliceBy([]int{1, 2, 3, 4, 5}, func(v int) bool { return v%2 == 0 })
+	expected := map[bool][]int{true: {2, 4}, false: {1, 3, 5}}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestKeySliceBy(t *testing.T) {
+	res := KeySliceBy([]string{"a1", "b1", "a2"}, func(v string) byte { return v[0] })
+	expected := map[byte]string{'a': "a2", 'b': "b1"}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected the last value to win %v, got %v", expected, res)
+	}
+}
+
+func TestChunkSlice(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        []int
+		size     int
+		expected [][]int
+	}{
+		{"empty", nil, 2, [][]int{}},
+		{"exact", []int{1, 2, 3, 4}, 2, [][]int{{1, 2}, {3, 4}}},
+		{"partial last chunk", []int{1, 2, 3, 4, 5}, 2, [][]int{{1, 2}, {3, 4}, {5}}},
+		{"size larger than slice", []int{1, 2}, 5, [][]int{{1, 2}}},
+		{"size 1", []int{1, 2}, 1, [][]int{{1}, {2}}},
+	}
+	for _, test := range tests {
+		if res := ChunkSlice(test.s, test.size); !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, res)
+		}
+	}
+
+	// Appending to a chunk must not overwrite the next chunk.
+	s := []int{1, 2, 3, 4}
+	chunks := ChunkSlice(s, 2)
+	_ = append(chunks[0], 9)
+	if !reflect.DeepEqual(s, []int{1, 2, 3, 4}) {
+		t.Fatalf("expected appending to a chunk not to modify the slice, got %v", s)
+	}
+}
+
+func TestChunkSlicePanicsOnInvalidSize(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	ChunkSlice([]int{1}, 0)
+}
+
+func TestPartitionSlice(t *testing.T) {
+	tests := []struct {
+		name         string
+		s            []int
+		expectedPass []int
+		expectedFail []int
+	}{
+		{"empty", nil, []int{}, []int{}},
+		{"mixed", []int{1, 2, 3, 4, 5}, []int{2, 4}, []int{1, 3, 5}},
+		{"all pass", []int{2, 4}, []int{2, 4}, []int{}},
+	}
+	for _, test := range tests {
+		pass, fail := PartitionSlice(test.s, func(v int) bool { return v%2 == 0 })
+		if !reflect.DeepEqual(pass, test.expectedPass) || !reflect.DeepEqual(fail, test.expectedFail) {
+			t.Errorf("%s: expected %v %v, got %v %v", test.name, test.expectedPass, test.expectedFail, pass, fail)
+		}
+	}
+}
+
+func TestSeq(t *testing.T) {
+	s := []int{1, 2, 2, 3, 4, 5, 6, 6}
+	tests := []struct {
+		name     string
+		seq      Seq[int]
+		expected []int
+	}{
+		{"slice", SliceSeq(s), s},
+		{"map", MapSeq(SliceSeq([]int{1, 2}), func(v int) int { return v * 10 }), []int{10, 20}},
+		{"filter", FilterSeq(SliceSeq(s), func(v int) bool { return v%2 == 0 }), []int{2, 2, 4, 6, 6}},
+		{"uniq", UniqSeq(SliceSeq(s)), []int{1, 2, 3, 4, 5, 6}},
+		{"diff", DiffSeq(SliceSeq(s), []int{2, 6}), []int{1, 3, 4, 5}},
+		{"diff nothing", DiffSeq(SliceSeq([]int{1}), nil), []int{1}},
+		{"take", TakeSeq(SliceSeq(s), 3), []int{1, 2, 2}},
+		{"take more than available", TakeSeq(SliceSeq([]int{1, 2}), 5), []int{1, 2}},
+		{"take 0", TakeSeq(SliceSeq(s), 0), []int{}},
+		{"take negative", TakeSeq(SliceSeq(s), -1), []int{}},
+	}
+	for _, test := range tests {
+		if res := CollectSeq(test.seq); !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, res)
+		}
+	}
+}
+
+func TestTakeSeqStopsPulling(t *testing.T) {
+	tests := []struct {
+		n              int
+		expectedPulled int
+	}{
+		{-1, 0},
+		{0, 0},
+		{2, 2},
+		{10, 5},
+	}
+	for _, test := range tests {
+		pulled := 0
+		CollectSeq(TakeSeq(countSeq([]int{1, 2, 3, 4, 5}, &pulled), test.n))
+		if pulled != test.expectedPulled {
+			t.Errorf("%d: expected %d values to be pulled, got %d", test.n, test.expectedPulled, pulled)
+		}
+	}
+}
+
+func TestChunkSeq(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        []int
+		size     int
+		expected [][]int
+	}{
+		{"empty", nil, 2, [][]int{}},
+		{"exact", []int{1, 2, 3, 4}, 2, [][]int{{1, 2}, {3, 4}}},
+		{"partial last chunk", []int{1, 2, 3, 4, 5}, 2, [][]int{{1, 2}, {3, 4}, {5}}},
+		{"size larger than seq", []int{1, 2}, 5, [][]int{{1, 2}}},
+	}
+	for _, test := range tests {
+		if res := CollectSeq(ChunkSeq(SliceSeq(test.s), test.size)); !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, res)
+		}
+	}
+}
+
+func TestChunkSeqStops(t *testing.T) {
+	tests := []struct {
+		name           string
+		s              []int
+		take           int
+		expected       [][]int
+		expectedPulled int
+	}{
+		// Stopping on a full chunk must not yield the partial chunk after it.
+		{"on a full chunk", []int{1, 2, 3, 4, 5}, 1, [][]int{{1, 2}}, 2},
+		{"on the partial final chunk", []int{1, 2, 3, 4, 5}, 3, [][]int{{1, 2}, {3, 4}, {5}}, 5},
+	}
+	for _, test := range tests {
+		pulled := 0
+		res := CollectSeq(TakeSeq(ChunkSeq(countSeq(test.s, &pulled), 2), test.take))
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, res)
+		}
+		if pulled != test.expectedPulled {
+			t.Errorf("%s: expected %d values to be pulled, got %d", test.name, test.expectedPulled, pulled)
+		}
+	}
+
+	// Breaking out of a range stops after the first chunk.
+	calls := 0
+	ChunkSeq(SliceSeq([]int{1, 2, 3, 4, 5}), 2)(func(chunk []int) bool {
+		calls++
+		return false
+	})
+	if calls != 1 {
+		t.Fatalf("expected 1 chunk to be yielded, got %d", calls)
+	}
+}
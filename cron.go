@@ -3,19 +3,69 @@ package wwgo
 import (
 	"context"
 	"fmt"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"github.com/robfig/cron/v3"
 	"github.com/rs/zerolog"
+	"math/rand"
+	"net/http"
+	"os"
+	"sync"
 	"time"
 )
 
 type CronFn func(ctx context.Context, log zerolog.Logger) error
 
+type CronJob struct {
+	// Must be unique, used for logs, alerts, locks & the run history.
+	Name string
+	// i.e. "0 1 * * *" for 1am every day.
+	// See https://godoc.org/github.com/robfig/cron#hdr-CRON_Expression_Format for more info.
+	Spec string
+	Fn   CronFn
+	// Optional, the job's context will be cancelled after this duration. This
+	// applies to each attempt.
+	Timeout time.Duration
+	// Optional, the number of times to retry a failed run before alerting.
+	Retries int
+	// Optional, the delay before the first retry, which then increases
+	// exponentially (default 30 seconds).
+	RetryInterval time.Duration
+	// Optional, scheduled runs will be delayed by a random duration up to this,
+	// so that many sites do not all hit shared APIs at the same moment.
+	Jitter time.Duration
+	// Optional, the alerter category for failures (default "api_error").
+	AlertCategory string
+	// Skip the run if the previous one is still going. If the CronTab has a
+	// Locker, this applies across all hosts.
+	SkipIfRunning bool
+}
+
+type CronTabOpt struct {
+	// Optional, records the start, end & result of every run.
+	RunStore CronRunStore
+	// Optional, used by jobs with SkipIfRunning to ensure only one host runs
+	// them at a time.
+	Locker CronLocker
+	// Optional, how long Run waits for running jobs when stopping before
+	// cancelling them (default 60 seconds).
+	ShutdownGracePeriod time.Duration
+}
+
+// CronTab runs the jobs on their schedules. Start does not block, so to run it
+// as a wwhttp.DaemonServer (i.e. with wwhttp.RunDaemon) use
+// wwhttp.DaemonServerFunc(cronTab.Run).
 type CronTab struct {
 	log      zerolog.Logger
 	alerter  CategoryAlerter
 	siteName string
-	crons    map[string]CronFn
+	jobs     []CronJob
 	timeZone *time.Location
+	hostname string
+	running  map[string]bool
+	mut      sync.Mutex
+	CronTabOpt
 }
 
 func NewCronTab(
@@ -27,17 +77,101 @@ func NewCronTab(
 	// See https://godoc.org/github.com/robfig/cron#hdr-CRON_Expression_Format for more info.
 	crons map[string]CronFn,
 ) (*CronTab, error) {
+	jobs := make([]CronJob, 0, len(crons))
+	for spec, fn := range crons {
+		jobs = append(jobs, CronJob{Name: spec, Spec: spec, Fn: fn})
+	}
+	return NewCronTabWithJobs(log, alerter, siteName, timeZone, jobs, CronTabOpt{})
+}
+
+func NewCronTabWithJobs(
+	log zerolog.Logger,
+	alerter CategoryAlerter,
+	siteName string,
+	timeZone *time.Location,
+	jobs []CronJob,
+	opt CronTabOpt,
+) (*CronTab, error) {
+	names := map[string]bool{}
+	for i, job := range jobs {
+		if job.Name == "" {
+			return nil, errors.Errorf("jobs[%d] has no name", i)
+		}
+		if names[job.Name] {
+			return nil, errors.Errorf("duplicate cron job name '%s'", job.Name)
+		}
+		names[job.Name] = true
+		if _, err := cron.ParseStandard(job.Spec); err != nil {
+			return nil, errors.Wrapf(err, "invalid spec for cron job '%s'", job.Name)
+		}
+		if job.AlertCategory == "" {
+			jobs[i].AlertCategory = "api_error"
+		}
+	}
+
+	if timeZone == nil {
+		timeZone = time.Local
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get hostname")
+	}
 	return &CronTab{
-		log:      log,
-		alerter:  alerter,
-		siteName: siteName,
-		crons:    crons,
-		timeZone: timeZone,
+		log:        log,
+		alerter:    alerter,
+		siteName:   siteName,
+		jobs:       jobs,
+		timeZone:   timeZone,
+		hostname:   hostname,
+		running:    map[string]bool{},
+		CronTabOpt: opt,
 	}, nil
 }
 
-// Start the crons in the background.
+type contextKey struct {
+	name string
+}
+
+var cronShutdownCtxKey = &contextKey{"cronShutdown"}
+
+// CronShutdownFromContext returns a channel that is closed when the CronTab
+// starts shutting down, so long-running jobs can stop cleanly. The job's
+// context itself is only cancelled once the grace period has expired.
+// The channel is nil (i.e. never closes) for jobs that were run manually.
+func CronShutdownFromContext(ctx context.Context) <-chan struct{} {
+	ch, _ := ctx.Value(cronShutdownCtxKey).(chan struct{})
+	return ch
+}
+
+// Start the crons in the background, see Run to wait for them to stop. It is
+// fatal if a job's spec is invalid.
 func (c *CronTab) Start(ctx context.Context) {
+	crons, wait, err := c.schedule(ctx)
+	if err != nil {
+		c.log.Fatal().Err(err).Msg("Failed to start crons")
+	}
+	crons.Start()
+	go wait()
+}
+
+// Run runs the crons until the context is cancelled, then waits up to the
+// ShutdownGracePeriod for any running jobs to finish. Like http.Server, it
+// returns http.ErrServerClosed once stopped so it can be used with
+// wwhttp.RunDaemon via wwhttp.DaemonServerFunc(cronTab.Run).
+func (c *CronTab) Run(ctx context.Context) error {
+	crons, wait, err := c.schedule(ctx)
+	if err != nil {
+		return err
+	}
+	crons.Start()
+	wait()
+	return http.ErrServerClosed
+}
+
+// schedule adds the jobs, returning a func that waits for the context to be
+// cancelled & then stops the crons.
+func (c *CronTab) schedule(ctx context.Context) (*cron.Cron, func(), error) {
 	log := c.log
 
 	crons := cron.New(
@@ -45,37 +179,254 @@ func (c *CronTab) Start(ctx context.Context) {
 		cron.WithLogger(zerologCronLogger{c.log}),
 	)
 
+	// Jobs get their own context so that they are not killed as soon as we
+	// are asked to stop.
+	shutdown := make(chan struct{})
+	jobsCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	jobsCtx = context.WithValue(jobsCtx, cronShutdownCtxKey, shutdown)
+
 	// Add crons.
-	for spec, fn := range c.crons {
-		if _, err := crons.AddFunc(spec, func() {
-			// Catch panics.
-			defer func() {
-				if r := recover(); r != nil {
-					log.Error().Str("panic", fmt.Sprintf("%+v", r)).Str("cron", spec).Msg("Panic in cron")
-					if innerErr := c.alerter.SendAlert(ctx, "api_error", fmt.Sprintf("Panic in cron '%s': %+v", spec, r)); innerErr != nil {
-						log.Err(innerErr).Msg("Failed to send alert")
-					}
-				}
-			}()
-			if err := fn(ctx, log); err != nil {
-				log.Err(err).Send()
-				if innerErr := c.alerter.SendAlert(ctx, "api_error", fmt.Sprintf("Cron job failed with error: %s", err)); innerErr != nil {
-					log.Err(innerErr).Msg("Failed to send alert")
+	for _, job := range c.jobs {
+		if _, err := crons.AddFunc(job.Spec, func() {
+			if job.Jitter > 0 {
+				delay := time.Duration(rand.Int63n(int64(job.Jitter)))
+				log.Debug().Str("cron", job.Name).Dur("delay", delay).Msg("Delaying cron start")
+				select {
+				case <-time.After(delay):
+				case <-shutdown:
+					return
 				}
 			}
+			_ = c.runJob(jobsCtx, job)
 		}); err != nil {
-			log.Fatal().Err(err).Msgf("Failed to add cron %s", spec)
+			cancelJobs()
+			return nil, nil, errors.Wrapf(err, "failed to add cron %s", job.Name)
 		}
 	}
 
-	// Run crons in background.
-	crons.Start()
+	wait := func() {
+		defer cancelJobs()
 
-	// Stop crons on context cancel.
-	go func() {
+		// Stop crons on context cancel.
 		<-ctx.Done()
-		crons.Stop()
+		close(shutdown)
+		stopped := crons.Stop()
+
+		// Wait for running jobs.
+		gracePeriod := c.ShutdownGracePeriod
+		if gracePeriod == 0 {
+			gracePeriod = 60 * time.Second
+		}
+		select {
+		case <-stopped.Done():
+			log.Debug().Msg("Crons stopped")
+		case <-time.After(gracePeriod):
+			log.Warn().Msgf("Crons did not stop within %s, cancelling", gracePeriod)
+			cancelJobs()
+			select {
+			case <-stopped.Done():
+			case <-time.After(5 * time.Second):
+				log.Error().Msg("Crons did not stop after being cancelled")
+			}
+		}
+	}
+	return crons, wait, nil
+}
+
+// Jobs returns the job definitions.
+func (c *CronTab) Jobs() []CronJob {
+	return c.jobs
+}
+
+// Job returns the job definition by name.
+func (c *CronTab) Job(name string) (CronJob, bool) {
+	for _, job := range c.jobs {
+		if job.Name == name {
+			return job, true
+		}
+	}
+	return CronJob{}, false
+}
+
+// RunJob runs the job immediately, with the same locking, history, panic &
+// alert handling as a scheduled run.
+func (c *CronTab) RunJob(ctx context.Context, name string) error {
+	job, ok := c.Job(name)
+	if !ok {
+		return errors.Errorf("unknown cron job '%s'", name)
+	}
+	return c.runJob(ctx, job)
+}
+
+// NextRun returns the next time the job is scheduled to run after t.
+func (c *CronTab) NextRun(job CronJob, t time.Time) time.Time {
+	schedule, err := cron.ParseStandard(job.Spec)
+	if err != nil {
+		panic(errors.Wrapf(err, "invalid spec for cron job '%s'", job.Name))
+	}
+	return schedule.Next(t.In(c.timeZone))
+}
+
+// PrevRun returns the last time the job was scheduled to run before t, or the
+// zero time if it has not been scheduled in the last year.
+func (c *CronTab) PrevRun(job CronJob, t time.Time) time.Time {
+	schedule, err := cron.ParseStandard(job.Spec)
+	if err != nil {
+		panic(errors.Wrapf(err, "invalid spec for cron job '%s'", job.Name))
+	}
+
+	// The schedule can only tell us the next time, so walk forward from
+	// increasingly far back until we find one.
+	t = t.In(c.timeZone)
+	for _, window := range []time.Duration{time.Hour, 24 * time.Hour, 31 * 24 * time.Hour, 366 * 24 * time.Hour} {
+		var prev time.Time
+		for next := schedule.Next(t.Add(-window)); !next.IsZero() && !next.After(t); next = schedule.Next(next) {
+			prev = next
+		}
+		if !prev.IsZero() {
+			return prev
+		}
+	}
+	return time.Time{}
+}
+
+func (c *CronTab) runJob(ctx context.Context, job CronJob) error {
+	log := c.log.With().Str("cron", job.Name).Logger()
+
+	// Skip if it is still running, here or elsewhere.
+	if job.SkipIfRunning {
+		if !c.markRunning(job.Name) {
+			log.Warn().Msg("Cron is still running, skipping")
+			return nil
+		}
+		defer c.markFinished(job.Name)
+
+		if c.Locker != nil {
+			lockName := "cron:" + job.Name
+			locked, err := c.Locker.TryLock(ctx, lockName)
+			if err != nil {
+				err = errors.Wrapf(err, "failed to lock cron '%s'", job.Name)
+				c.alertErr(ctx, log, job, err)
+				return err
+			}
+			if !locked {
+				log.Info().Msg("Cron is running on another host, skipping")
+				return nil
+			}
+			defer func() {
+				if err := c.Locker.Unlock(ctx, lockName); err != nil {
+					log.Err(err).Msg("Failed to unlock cron")
+				}
+			}()
+		}
+	}
+
+	// Record the start.
+	run := &CronRun{
+		Id:        uuid.New(),
+		Job:       job.Name,
+		Host:      c.hostname,
+		StartedAt: time.Now(),
+	}
+	if c.RunStore != nil {
+		if err := c.RunStore.StartRun(ctx, run); err != nil {
+			log.Err(err).Msg("Failed to record cron start")
+		}
+	}
+
+	// Run, retrying with an exponential backoff if required. Stop retrying if
+	// we are shutting down.
+	retryCtx, cancelRetry := context.WithCancel(ctx)
+	defer cancelRetry()
+	go func() {
+		select {
+		case <-CronShutdownFromContext(ctx):
+			cancelRetry()
+		case <-retryCtx.Done():
+		}
+	}()
+	retryBackoff := backoff.NewExponentialBackOff()
+	retryBackoff.InitialInterval = 30 * time.Second
+	if job.RetryInterval != 0 {
+		retryBackoff.InitialInterval = job.RetryInterval
+	}
+	retryBackoff.MaxElapsedTime = 0
+	// NOTE: RetryNotify returns the context error if we stop early, we want the
+	// error from the job itself.
+	var err error
+	_ = backoff.RetryNotify(
+		func() error {
+			run.Attempts++
+			err = c.callJob(ctx, log, job)
+			return err
+		},
+		backoff.WithContext(backoff.WithMaxRetries(retryBackoff, uint64(job.Retries)), retryCtx),
+		func(err error, delay time.Duration) {
+			log.Warn().Err(err).Msgf("Cron failed, retrying in %s (attempt %d/%d)", delay, run.Attempts, job.Retries+1)
+		},
+	)
+	if err != nil {
+		c.alertErr(ctx, log, job, err)
+	}
+
+	// Record the end.
+	run.finish(err)
+	if c.RunStore != nil {
+		if err := c.RunStore.EndRun(ctx, run); err != nil {
+			log.Err(err).Msg("Failed to record cron end")
+		}
+	}
+	log.Debug().Dur("duration", run.Duration).Msg("Cron finished")
+	return err
+}
+
+// callJob runs a single attempt of the job, converting a panic to an error.
+func (c *CronTab) callJob(ctx context.Context, log zerolog.Logger, job CronJob) (res error) {
+	if job.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
+	// Catch panics.
+	defer func() {
+		if r := recover(); r != nil {
+			res = NewPanicError(r)
+			log.Error().Stack().Err(res).Str("panic", fmt.Sprintf("%+v", r)).Msg("Panic in cron")
+		}
 	}()
+	if err := job.Fn(ctx, log); err != nil {
+		log.Err(err).Send()
+		return err
+	}
+	return nil
+}
+
+func (c *CronTab) alertErr(ctx context.Context, log zerolog.Logger, job CronJob, err error) {
+	msg := fmt.Sprintf("Cron job '%s' failed with error: %s", job.Name, err)
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		msg = fmt.Sprintf("Panic in cron '%s': %+v", job.Name, panicErr.Recovered)
+	}
+	if innerErr := c.alerter.SendAlert(ctx, job.AlertCategory, msg); innerErr != nil {
+		log.Err(innerErr).Msg("Failed to send alert")
+	}
+}
+
+func (c *CronTab) markRunning(job string) bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if c.running[job] {
+		return false
+	}
+	c.running[job] = true
+	return true
+}
+
+func (c *CronTab) markFinished(job string) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	delete(c.running, job)
 }
 
 type zerologCronLogger struct {
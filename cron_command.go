@@ -0,0 +1,113 @@
+package wwgo
+
+import (
+	"fmt"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	"os"
+	"time"
+)
+
+func CronCommand(cronTab func() *CronTab) *cli.Command {
+	return &cli.Command{
+		Name:  "cron",
+		Usage: "Cron commands",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the cron jobs with their previous & next scheduled times",
+				Action: func(ctx *cli.Context) error {
+					c := cronTab()
+					now := time.Now()
+
+					table := tablewriter.NewWriter(os.Stdout)
+					table.SetHeader([]string{
+						"Name",
+						"Spec",
+						"Previous",
+						"Next",
+					})
+					for _, job := range c.Jobs() {
+						prev := "-"
+						if t := c.PrevRun(job, now); !t.IsZero() {
+							prev = t.Format(time.RFC822)
+						}
+						table.Append([]string{
+							job.Name,
+							job.Spec,
+							prev,
+							c.NextRun(job, now).Format(time.RFC822),
+						})
+					}
+					table.Render()
+					return nil
+				},
+			},
+			{
+				Name:      "run",
+				Usage:     "Run a cron job now",
+				ArgsUsage: "<name>",
+				Action: func(ctx *cli.Context) error {
+					if ctx.NArg() < 1 {
+						fmt.Println("Argument required: job name")
+						os.Exit(1)
+					}
+					if err := cronTab().RunJob(ctx.Context, ctx.Args().Get(0)); err != nil {
+						return err
+					}
+					fmt.Println("👍️")
+					return nil
+				},
+			},
+			{
+				Name:  "status",
+				Usage: "Show the last run of each cron job",
+				Action: func(ctx *cli.Context) error {
+					c := cronTab()
+					if c.RunStore == nil {
+						return errors.Errorf("the cron tab has no RunStore")
+					}
+
+					table := tablewriter.NewWriter(os.Stdout)
+					table.SetHeader([]string{
+						"Name",
+						"Last Run",
+						"Host",
+						"Duration",
+						"Result",
+					})
+					for _, job := range c.Jobs() {
+						runs, err := c.RunStore.LastRuns(ctx.Context, job.Name, 1)
+						if err != nil {
+							return err
+						}
+						if len(runs) == 0 {
+							table.Append([]string{job.Name, "never", "", "", ""})
+							continue
+						}
+						run := runs[0]
+						duration := "-"
+						result := "running"
+						if !run.IsRunning() {
+							duration = run.Duration.Round(time.Millisecond).String()
+							result = "ok"
+							if run.Error != nil {
+								result = TruncateStr(*run.Error, 60)
+							}
+						}
+						table.Append([]string{
+							job.Name,
+							run.StartedAt.In(c.timeZone).Format(time.RFC822),
+							run.Host,
+							duration,
+							result,
+						})
+					}
+					table.Render()
+					return nil
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,104 @@
+package wwgo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CronRun is a record of a single execution of a cron job.
+type CronRun struct {
+	Id        uuid.UUID
+	Job       string
+	Host      string
+	StartedAt time.Time
+	// The number of times the job was called, including retries.
+	Attempts int
+	// Nil whilst the job is still running.
+	EndedAt  *time.Time
+	Duration time.Duration
+	// Nil if the job succeeded.
+	Error *string
+}
+
+// IsRunning returns true if the run has not finished (or the host died before
+// it could record the end).
+func (r *CronRun) IsRunning() bool {
+	return r.EndedAt == nil
+}
+
+func (r *CronRun) finish(err error) {
+	endedAt := time.Now()
+	r.EndedAt = &endedAt
+	r.Duration = endedAt.Sub(r.StartedAt)
+	if err != nil {
+		r.Error = ToPtr(err.Error())
+	}
+}
+
+// CronRunStore persists the history of cron runs.
+type CronRunStore interface {
+	StartRun(ctx context.Context, run *CronRun) error
+	EndRun(ctx context.Context, run *CronRun) error
+	// LastRuns returns the most recent runs of the job, newest first.
+	LastRuns(ctx context.Context, job string, limit int) ([]*CronRun, error)
+}
+
+// CronLocker is used to ensure only one host runs a job at a time.
+type CronLocker interface {
+	// TryLock must not wait for the lock, it should return false if the lock is
+	// held elsewhere.
+	TryLock(ctx context.Context, name string) (bool, error)
+	Unlock(ctx context.Context, name string) error
+}
+
+// MemoryCronRunStore is an in-memory CronRunStore, intended for tests & local
+// dev.
+type MemoryCronRunStore struct {
+	mut  sync.RWMutex
+	runs []CronRun
+}
+
+func NewMemoryCronRunStore() *MemoryCronRunStore {
+	return &MemoryCronRunStore{}
+}
+
+func (s *MemoryCronRunStore) StartRun(ctx context.Context, run *CronRun) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.runs = append(s.runs, *run)
+	return nil
+}
+
+func (s *MemoryCronRunStore) EndRun(ctx context.Context, run *CronRun) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for i := range s.runs {
+		if s.runs[i].Id == run.Id {
+			s.runs[i] = *run
+			return nil
+		}
+	}
+	s.runs = append(s.runs, *run)
+	return nil
+}
+
+func (s *MemoryCronRunStore) LastRuns(ctx context.Context, job string, limit int) ([]*CronRun, error) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	res := make([]*CronRun, 0)
+	for _, run := range s.runs {
+		if run.Job == job {
+			res = append(res, ToPtr(run))
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].StartedAt.After(res[j].StartedAt)
+	})
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
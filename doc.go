@@ -0,0 +1,16 @@
+// Package wwgo contains shared helpers for Weaving Webs Go projects.
+//
+// # Migrating from the typed slice helpers
+//
+// The typed helpers in util.go are deprecated in favour of the generic
+// collection helpers:
+//   - ArrayDiffInt32, ArrayDiffInt, ArrayDiffStr, ArrayDiffUuid & ArrayDiffUuidRef: DiffSlice.
+//   - ArrayIncludesInt, ArrayIncludesInt32, ArrayIncludesStr & ArrayIncludesUUID: SliceIncludes,
+//     or SliceToSet when checking many values.
+//   - ArrayFilterFnStr: FilterSlice.
+//   - ArrayMapStr & IntArray2StrArray: MapSlice i.e. MapSlice(ints, strconv.Itoa).
+//   - UuidRef, StrRef, IntRef etc: ToPtr.
+//
+// Unlike the old helpers, DiffSlice, IntersectSlice & UnionSlice use a set so
+// they are O(n+m).
+package wwgo
@@ -6,6 +6,8 @@ import (
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
 	"runtime"
+	"strings"
+	"sync"
 )
 
 // stack is a direct copy from pkg/errors.
@@ -44,14 +46,35 @@ func PrintErrWithStack(err error) {
 type ClientError struct {
 	message       string
 	code          string
+	fields        []ClientErrorField
 	originalError error
 	stack         *stack
 }
 
+// ClientErrorField describes a problem with a specific input field.
+type ClientErrorField struct {
+	// i.e. "input.addresses[0].postcode".
+	Path    string                 `json:"path"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
 func (err *ClientError) GqlErrorCode() string {
 	return err.code
 }
 
+// Fields returns the field-level details, if any.
+func (err *ClientError) Fields() []ClientErrorField {
+	return err.fields
+}
+
+// AddField appends a field-level detail to the error.
+func (err *ClientError) AddField(field ClientErrorField) *ClientError {
+	err.fields = append(err.fields, field)
+	return err
+}
+
 func (err *ClientError) Error() string {
 	return err.message
 }
@@ -73,8 +96,89 @@ func NewClientError(code string, message string, err error) *ClientError {
 	}
 }
 
+// NewClientFieldsError creates a ClientError with field-level details i.e.
+// for validation errors.
+func NewClientFieldsError(code string, message string, fields []ClientErrorField) *ClientError {
+	return &ClientError{
+		message: message,
+		code:    code,
+		fields:  fields,
+		stack:   callers(),
+	}
+}
+
+// PanicError is a recovered panic, with the stack of where it happened.
+type PanicError struct {
+	Recovered interface{}
+	stack     *stack
+}
+
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("caught panic: %v", err.Recovered)
+}
+
+// Unwrap returns the panic value if it was an error.
+func (err *PanicError) Unwrap() error {
+	recoveredErr, _ := err.Recovered.(error)
+	return recoveredErr
+}
+
+func (err *PanicError) StackTrace() errors.StackTrace {
+	return err.stack.StackTrace()
+}
+
+// NewPanicError must be called directly from the deferred function that
+// recovered the panic, so that the stack starts at the panic.
+func NewPanicError(recovered interface{}) *PanicError {
+	const depth = 32
+	var pcs [depth]uintptr
+	// Skip runtime.Callers, NewPanicError, the deferred func & runtime.gopanic.
+	n := runtime.Callers(4, pcs[:])
+	var st stack = pcs[0:n]
+	return &PanicError{
+		Recovered: recovered,
+		stack:     &st,
+	}
+}
+
+// MultiError is a list of errors, i.e. from a collecting ErrAndPanicGroup.
+type MultiError struct {
+	errs []error
+}
+
+// NewMultiError returns nil if there are no errors.
+func NewMultiError(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &MultiError{errs: errs}
+}
+
+func (err *MultiError) Error() string {
+	return fmt.Sprintf("%d %s occurred: %s", len(err.errs), Plural(len(err.errs), "error", "errors"), JoinErrors(err.errs, "; "))
+}
+
+func (err *MultiError) Errors() []error {
+	return err.errs
+}
+
+// Unwrap allows errors.Is & errors.As to check each error.
+func (err *MultiError) Unwrap() []error {
+	return err.errs
+}
+
+// JoinErrors joins the messages of the errors.
+func JoinErrors(errs []error, sep string) string {
+	return strings.Join(MapSlice(errs, func(err error) string {
+		return err.Error()
+	}), sep)
+}
+
 type ErrAndPanicGroup struct {
-	gos *errgroup.Group
+	gos        *errgroup.Group
+	collectAll bool
+	errs       []error
+	mut        sync.Mutex
 }
 
 func NewErrAndPanicGroup() *ErrAndPanicGroup {
@@ -90,17 +194,56 @@ func NewErrAndPanicGroupWithContext(ctx context.Context) (*ErrAndPanicGroup, con
 	}, ctx
 }
 
+// NewErrAndPanicGroupCollectAll creates a group that does not stop at the
+// first error, Wait returns a *MultiError of every error instead.
+func NewErrAndPanicGroupCollectAll() *ErrAndPanicGroup {
+	return &ErrAndPanicGroup{
+		gos:        &errgroup.Group{},
+		collectAll: true,
+	}
+}
+
+// SetLimit limits the number of goroutines running at once, see
+// errgroup.Group.SetLimit.
+func (g *ErrAndPanicGroup) SetLimit(n int) {
+	g.gos.SetLimit(n)
+}
+
+// Go runs fn in a goroutine, converting any panic to a *PanicError. If a limit
+// is set, this blocks until there is room.
 func (g *ErrAndPanicGroup) Go(fn func() error) {
-	g.gos.Go(func() (res error) {
+	g.gos.Go(g.wrap(fn))
+}
+
+// TryGo is like Go but returns false instead of blocking if the limit has
+// been reached.
+func (g *ErrAndPanicGroup) TryGo(fn func() error) bool {
+	return g.gos.TryGo(g.wrap(fn))
+}
+
+func (g *ErrAndPanicGroup) wrap(fn func() error) func() error {
+	return func() (res error) {
 		defer func() {
 			if r := recover(); r != nil {
-				res = errors.Errorf("caught panic: %v", r)
+				res = NewPanicError(r)
+			}
+			if res != nil && g.collectAll {
+				g.mut.Lock()
+				g.errs = append(g.errs, res)
+				g.mut.Unlock()
+				res = nil
 			}
 		}()
 		return fn()
-	})
+	}
 }
 
 func (g *ErrAndPanicGroup) Wait() error {
-	return g.gos.Wait()
+	if err := g.gos.Wait(); err != nil {
+		return err
+	}
+	if len(g.errs) != 0 {
+		return &MultiError{errs: g.errs}
+	}
+	return nil
 }
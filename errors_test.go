@@ -0,0 +1,154 @@
+package wwgo
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//go:noinline
+func panicForTest() error {
+	panic("boom")
+}
+
+func TestPanicErrorStack(t *testing.T) {
+	g := NewErrAndPanicGroup()
+	g.Go(panicForTest)
+	err := g.Wait()
+
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected a PanicError, got %v", err)
+	}
+	if panicErr.Recovered != "boom" {
+		t.Fatalf("expected the recovered value, got %v", panicErr.Recovered)
+	}
+	st := panicErr.StackTrace()
+	if len(st) == 0 {
+		t.Fatal("expected a stack trace")
+	}
+	if fn := fmt.Sprintf("%n", st[0]); fn != "panicForTest" {
+		t.Fatalf("expected the stack to start at the panicking function, got %s\n%+v", fn, st)
+	}
+}
+
+func TestPanicErrorUnwrap(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	g := NewErrAndPanicGroup()
+	g.Go(func() error {
+		panic(sentinel)
+	})
+	if err := g.Wait(); !errors.Is(err, sentinel) {
+		t.Fatalf("expected the panic error to unwrap, got %v", err)
+	}
+}
+
+func TestErrAndPanicGroupCollectAll(t *testing.T) {
+	errA := errors.New("a")
+	errB := errors.New("b")
+	g := NewErrAndPanicGroupCollectAll()
+	g.Go(func() error { return errA })
+	g.Go(func() error { return nil })
+	g.Go(func() error { return errB })
+	g.Go(func() error { panic("boom") })
+	err := g.Wait()
+
+	var multiErr *MultiError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("expected a MultiError, got %v", err)
+	}
+	if len(multiErr.Errors()) != 3 {
+		t.Fatalf("expected 3 errors, got %v", multiErr.Errors())
+	}
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("expected every error to be collected, got %v", err)
+	}
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected the panic to be collected, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "3 errors occurred: ") {
+		t.Fatalf("unexpected message %s", err)
+	}
+}
+
+func TestErrAndPanicGroupStopsAtFirstError(t *testing.T) {
+	errA := errors.New("a")
+	g := NewErrAndPanicGroup()
+	g.Go(func() error { return errA })
+	if err := g.Wait(); err != errA {
+		t.Fatalf("expected the error, got %v", err)
+	}
+}
+
+func TestNewMultiError(t *testing.T) {
+	if err := NewMultiError(nil); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	err := NewMultiError([]error{errors.New("a")})
+	if err == nil || err.Error() != "1 error occurred: a" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestErrAndPanicGroupTryGoLimit(t *testing.T) {
+	g := NewErrAndPanicGroup()
+	g.SetLimit(2)
+
+	release := make(chan struct{})
+	var running atomic.Int32
+	block := func() error {
+		running.Add(1)
+		<-release
+		return nil
+	}
+	if !g.TryGo(block) || !g.TryGo(block) {
+		t.Fatal("expected the goroutines within the limit to start")
+	}
+	if g.TryGo(block) {
+		t.Fatal("expected TryGo to fail once the limit is reached")
+	}
+
+	close(release)
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if running.Load() != 2 {
+		t.Fatalf("expected 2 goroutines to run, got %d", running.Load())
+	}
+	if !g.TryGo(func() error { return nil }) {
+		t.Fatal("expected TryGo to succeed once there is room")
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestErrAndPanicGroupGoBlocksAtLimit(t *testing.T) {
+	g := NewErrAndPanicGroup()
+	g.SetLimit(1)
+
+	release := make(chan struct{})
+	g.Go(func() error {
+		<-release
+		return nil
+	})
+	started := make(chan struct{})
+	go func() {
+		g.Go(func() error { return nil })
+		close(started)
+	}()
+	select {
+	case <-started:
+		t.Fatal("expected Go to block until there is room")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	<-started
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
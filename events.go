@@ -2,22 +2,49 @@ package wwgo
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
+	"path"
 	"sync"
+	"time"
 )
 
 type Events[T any] struct {
-	log     zerolog.Logger
-	watched map[uuid.UUID]*WatchedEventsGroup[T]
-	mut     sync.RWMutex
+	log           zerolog.Logger
+	watched       map[uuid.UUID]*WatchedEventsGroup[T]
+	subscriptions map[uuid.UUID]*eventSubscription[T]
+	replay        []replayedEvent[T]
+	opt           EventsOpt
+	mut           sync.RWMutex
+}
+
+type EventsOpt struct {
+	// Optional, distributes events between processes (see Start). By default,
+	// events are only delivered within this process.
+	Backend EventsBackend
+	// Optional, how long triggered events are kept for replaying to new
+	// subscriptions.
+	ReplayWindow time.Duration
+	// Optional, the maximum number of events kept for replay (default 100).
+	ReplayLimit int
 }
 
 func NewEvents[T any](log zerolog.Logger) *Events[T] {
+	return NewEventsWithOpt[T](log, EventsOpt{})
+}
+
+func NewEventsWithOpt[T any](log zerolog.Logger, opt EventsOpt) *Events[T] {
+	if opt.ReplayLimit == 0 {
+		opt.ReplayLimit = 100
+	}
 	return &Events[T]{
-		log:     log,
-		watched: map[uuid.UUID]*WatchedEventsGroup[T]{},
-		mut:     sync.RWMutex{},
+		log:           log,
+		watched:       map[uuid.UUID]*WatchedEventsGroup[T]{},
+		subscriptions: map[uuid.UUID]*eventSubscription[T]{},
+		opt:           opt,
+		mut:           sync.RWMutex{},
 	}
 }
 
@@ -29,7 +56,9 @@ type WatchedEventsGroup[T any] struct {
 func (f *Events[T]) WatchForEvents(events []string) uuid.UUID {
 	group := &WatchedEventsGroup[T]{
 		eventNames: events,
-		done:       make(chan *T),
+		// Buffered so that the result is not lost if the events are triggered
+		// before WaitForGroup is called.
+		done: make(chan *T, 1),
 	}
 	id := uuid.New()
 	f.mut.Lock()
@@ -42,6 +71,9 @@ func (f *Events[T]) WatchForEvent(event string) uuid.UUID {
 	return f.WatchForEvents([]string{event})
 }
 
+// WaitForGroup waits for all the events in the group, returning nil if the
+// context is cancelled or the watch is cancelled. The result is kept if the
+// events are triggered first, so every watch must be waited for or cancelled.
 func (f *Events[T]) WaitForGroup(ctx context.Context, id uuid.UUID) *T {
 	f.mut.RLock()
 	group, ok := f.watched[id]
@@ -50,6 +82,11 @@ func (f *Events[T]) WaitForGroup(ctx context.Context, id uuid.UUID) *T {
 		return nil
 	}
 
+	defer func() {
+		f.mut.Lock()
+		delete(f.watched, id)
+		f.mut.Unlock()
+	}()
 	select {
 	case res := <-group.done:
 		return res
@@ -59,40 +96,101 @@ func (f *Events[T]) WaitForGroup(ctx context.Context, id uuid.UUID) *T {
 }
 
 func (f *Events[T]) CancelWatch(id uuid.UUID) {
-	f.mut.RLock()
+	f.mut.Lock()
+	defer f.mut.Unlock()
 	group, ok := f.watched[id]
-	f.mut.RUnlock()
 	if !ok {
 		return
 	}
-
-	f.mut.Lock()
-	defer f.mut.Unlock()
 	delete(f.watched, id)
 	close(group.done)
 }
 
+// TriggerEvent delivers the event to the watchers & subscribers, via the
+// Backend if there is one, logging any error.
 func (f *Events[T]) TriggerEvent(eventName string, event *T) {
+	if err := f.TryTriggerEvent(context.Background(), eventName, event); err != nil {
+		f.log.Err(err).Str("event", eventName).Msg("Failed to trigger event")
+	}
+}
+
+// TryTriggerEvent is like TriggerEvent but returns the error from the Backend.
+func (f *Events[T]) TryTriggerEvent(ctx context.Context, eventName string, event *T) error {
+	if f.opt.Backend == nil {
+		f.dispatch(eventName, event)
+		return nil
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode event '%s'", eventName)
+	}
+	return f.opt.Backend.Publish(ctx, eventName, payload)
+}
+
+// Start receives events from the Backend until the context is cancelled. It
+// is only required if there is a Backend.
+func (f *Events[T]) Start(ctx context.Context) error {
+	if f.opt.Backend == nil {
+		return errors.Errorf("Events has no Backend")
+	}
+	return f.opt.Backend.Listen(ctx, func(eventName string, payload []byte) {
+		var event *T
+		if err := json.Unmarshal(payload, &event); err != nil {
+			f.log.Err(err).Str("event", eventName).Msg("Failed to decode event")
+			return
+		}
+		f.dispatch(eventName, event)
+	})
+}
+
+func (f *Events[T]) dispatch(eventName string, event *T) {
 	f.mut.Lock()
-	defer f.mut.Unlock()
-	for id, group := range f.watched {
+	for _, group := range f.watched {
 		if !SliceIncludes(group.eventNames, eventName) {
 			continue
 		}
 
-		// Remove the file from the watchlist, and the whole group if it was the
-		// last one.
+		// Remove the event from the group, and send the result if it was the
+		// last one. The group is kept until WaitForGroup or CancelWatch.
 		group.eventNames = DiffSlice(group.eventNames, []string{eventName})
 		if len(group.eventNames) == 0 {
+			// The channel is buffered, so if nobody has started listening yet they
+			// will get the result as soon as they call WaitForGroup.
 			select {
 			case group.done <- event:
 			default:
-				// If nobody has started listening yet, we just carry on & close the
-				// channel. They will get an immediate 'true' from the WaitForGroup.
 			}
+		}
+	}
+
+	// Keep for replay.
+	if f.opt.ReplayWindow > 0 {
+		f.replay = append(f.pruneReplay(), replayedEvent[T]{
+			name:  eventName,
+			event: event,
+			at:    time.Now(),
+		})
+		if len(f.replay) > f.opt.ReplayLimit {
+			f.replay = f.replay[len(f.replay)-f.opt.ReplayLimit:]
+		}
+	}
 
-			delete(f.watched, id)
-			close(group.done)
+	// Find the subscribers, we send outside the lock so a blocking subscriber
+	// does not hold up everything else.
+	var subs []*eventSubscription[T]
+	for _, sub := range f.subscriptions {
+		if sub.matches(eventName) {
+			subs = append(subs, sub)
+		}
+	}
+	f.mut.Unlock()
+
+	for _, sub := range subs {
+		if sub.send(event) {
+			continue
+		}
+		if dropped, ok := sub.dropped(); ok {
+			f.log.Warn().Str("pattern", sub.pattern).Int("dropped", dropped).Msg("Subscriber buffer is full, dropped events")
 		}
 	}
 }
@@ -105,5 +203,148 @@ func (f *Events[T]) EventIsBeingWatched(eventName string) bool {
 			return true
 		}
 	}
+	for _, sub := range f.subscriptions {
+		if sub.matches(eventName) {
+			return true
+		}
+	}
 	return false
 }
+
+type SubscribePolicy int
+
+const (
+	// SubscribeDrop drops events for the subscriber if its buffer is full.
+	SubscribeDrop SubscribePolicy = iota
+	// SubscribeBlock makes TriggerEvent wait until the subscriber has room in
+	// its buffer (or the subscription is cancelled).
+	SubscribeBlock
+)
+
+type SubscribeOpt struct {
+	// Optional, the size of the channel buffer (default 16).
+	Buffer int
+	// Optional, what to do when the buffer is full (default SubscribeDrop).
+	Policy SubscribePolicy
+	// Send any matching events from the replay window (see EventsOpt) first.
+	// These are dropped if they do not fit in the buffer.
+	Replay bool
+}
+
+// Subscribe returns a channel that receives every event with a name matching
+// the pattern (see path.Match i.e. "order:*") until the context is cancelled,
+// at which point the channel is closed.
+func (f *Events[T]) Subscribe(ctx context.Context, pattern string, opt SubscribeOpt) <-chan *T {
+	if opt.Buffer == 0 {
+		opt.Buffer = 16
+	}
+	sub := &eventSubscription[T]{
+		ctx:     ctx,
+		pattern: pattern,
+		policy:  opt.Policy,
+		ch:      make(chan *T, opt.Buffer),
+	}
+	id := uuid.New()
+
+	f.mut.Lock()
+	if opt.Replay {
+		for _, e := range f.pruneReplay() {
+			if !sub.matches(e.name) {
+				continue
+			}
+			select {
+			case sub.ch <- e.event:
+			default:
+			}
+		}
+	}
+	f.subscriptions[id] = sub
+	f.mut.Unlock()
+
+	// Clean up when cancelled.
+	go func() {
+		<-ctx.Done()
+		f.mut.Lock()
+		delete(f.subscriptions, id)
+		f.mut.Unlock()
+		sub.close()
+	}()
+
+	return sub.ch
+}
+
+// pruneReplay must be called whilst holding the lock.
+func (f *Events[T]) pruneReplay() []replayedEvent[T] {
+	cutoff := time.Now().Add(-f.opt.ReplayWindow)
+	for len(f.replay) != 0 && f.replay[0].at.Before(cutoff) {
+		f.replay = f.replay[1:]
+	}
+	return f.replay
+}
+
+type replayedEvent[T any] struct {
+	name  string
+	event *T
+	at    time.Time
+}
+
+type eventSubscription[T any] struct {
+	ctx     context.Context
+	pattern string
+	policy  SubscribePolicy
+	ch      chan *T
+	closed  bool
+	mut     sync.Mutex
+	// Dropped events since the last warning.
+	droppedCount int
+	lastWarning  time.Time
+}
+
+func (s *eventSubscription[T]) matches(eventName string) bool {
+	ok, _ := path.Match(s.pattern, eventName)
+	return ok
+}
+
+// send returns false if the event was dropped.
+func (s *eventSubscription[T]) send(event *T) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.closed {
+		return true
+	}
+	if s.policy == SubscribeBlock {
+		select {
+		case s.ch <- event:
+		case <-s.ctx.Done():
+		}
+		return true
+	}
+	select {
+	case s.ch <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// dropped counts a dropped event, returning the count if it is time to warn
+// about them (at most once a minute).
+func (s *eventSubscription[T]) dropped() (int, bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.droppedCount++
+	if time.Since(s.lastWarning) < time.Minute {
+		return 0, false
+	}
+	count := s.droppedCount
+	s.droppedCount = 0
+	s.lastWarning = time.Now()
+	return count, true
+}
+
+func (s *eventSubscription[T]) close() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.closed = true
+	close(s.ch)
+}
@@ -0,0 +1,51 @@
+package wwgo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"sync"
+)
+
+// EventsBackend distributes events between instances of Events, i.e. in
+// different processes.
+type EventsBackend interface {
+	// Publish sends the event to every listener, including ones in this process.
+	Publish(ctx context.Context, eventName string, payload []byte) error
+	// Listen blocks, calling fn for every published event until the context is
+	// cancelled.
+	Listen(ctx context.Context, fn func(eventName string, payload []byte)) error
+}
+
+// MemoryEventsBackend is an in-process EventsBackend, intended for tests.
+type MemoryEventsBackend struct {
+	listeners map[uuid.UUID]func(eventName string, payload []byte)
+	mut       sync.RWMutex
+}
+
+func NewMemoryEventsBackend() *MemoryEventsBackend {
+	return &MemoryEventsBackend{
+		listeners: map[uuid.UUID]func(eventName string, payload []byte){},
+	}
+}
+
+func (b *MemoryEventsBackend) Publish(ctx context.Context, eventName string, payload []byte) error {
+	b.mut.RLock()
+	defer b.mut.RUnlock()
+	for _, fn := range b.listeners {
+		fn(eventName, payload)
+	}
+	return nil
+}
+
+func (b *MemoryEventsBackend) Listen(ctx context.Context, fn func(eventName string, payload []byte)) error {
+	id := uuid.New()
+	b.mut.Lock()
+	b.listeners[id] = fn
+	b.mut.Unlock()
+
+	<-ctx.Done()
+	b.mut.Lock()
+	delete(b.listeners, id)
+	b.mut.Unlock()
+	return nil
+}
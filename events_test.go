@@ -0,0 +1,74 @@
+package wwgo
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"testing"
+	"time"
+)
+
+func TestEventsTriggerBeforeWait(t *testing.T) {
+	events := NewEvents[string](zerolog.Nop())
+	id := events.WatchForEvents([]string{"a", "b"})
+
+	a, b := "a", "b"
+	events.TriggerEvent("a", &a)
+	events.TriggerEvent("b", &b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	res := events.WaitForGroup(ctx, id)
+	if res == nil || *res != "b" {
+		t.Fatalf("expected the last event, got %v", res)
+	}
+	if _, ok := events.watched[id]; ok {
+		t.Fatalf("expected the group to be removed after waiting")
+	}
+}
+
+func TestEventsWaitThenTrigger(t *testing.T) {
+	events := NewEvents[string](zerolog.Nop())
+	id := events.WatchForEvent("a")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		a := "a"
+		events.TriggerEvent("a", &a)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if res := events.WaitForGroup(ctx, id); res == nil || *res != "a" {
+		t.Fatalf("expected the event, got %v", res)
+	}
+}
+
+func TestEventsCancelWatch(t *testing.T) {
+	events := NewEvents[string](zerolog.Nop())
+	id := events.WatchForEvent("a")
+	events.CancelWatch(id)
+
+	if res := events.WaitForGroup(context.Background(), id); res != nil {
+		t.Fatalf("expected nil after cancel, got %v", *res)
+	}
+	if events.EventIsBeingWatched("a") {
+		t.Fatalf("expected the event to no longer be watched")
+	}
+}
+
+func TestEventsSubscribeDrop(t *testing.T) {
+	events := NewEvents[int](zerolog.Nop())
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := events.Subscribe(ctx, "n:*", SubscribeOpt{Buffer: 2})
+
+	for i := 0; i < 5; i++ {
+		events.TriggerEvent("n:x", &i)
+	}
+	if len(ch) != 2 {
+		t.Fatalf("expected 2 buffered events, got %d", len(ch))
+	}
+
+	cancel()
+	for range ch {
+	}
+}
@@ -0,0 +1,290 @@
+package wwgo
+
+import (
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PluralCategory is a CLDR plural category.
+type PluralCategory string
+
+const (
+	PluralZero  PluralCategory = "zero"
+	PluralOne   PluralCategory = "one"
+	PluralTwo   PluralCategory = "two"
+	PluralFew   PluralCategory = "few"
+	PluralMany  PluralCategory = "many"
+	PluralOther PluralCategory = "other"
+)
+
+// PluralForms are the words for each PluralCategory, empty forms fall back to
+// Other.
+type PluralForms struct {
+	Zero  string
+	One   string
+	Two   string
+	Few   string
+	Many  string
+	Other string
+}
+
+// NewPluralForms creates the forms from a list for templates, either one &
+// other (like Plural) or zero, one, two, few, many & other.
+func NewPluralForms(forms ...string) PluralForms {
+	switch len(forms) {
+	case 2:
+		return PluralForms{One: forms[0], Other: forms[1]}
+	case 6:
+		return PluralForms{Zero: forms[0], One: forms[1], Two: forms[2], Few: forms[3], Many: forms[4], Other: forms[5]}
+	}
+	panic(fmt.Sprintf("NewPluralForms: expected 2 or 6 forms, got %d", len(forms)))
+}
+
+func (f PluralForms) For(category PluralCategory) string {
+	res := ""
+	switch category {
+	case PluralZero:
+		res = f.Zero
+	case PluralOne:
+		res = f.One
+	case PluralTwo:
+		res = f.Two
+	case PluralFew:
+		res = f.Few
+	case PluralMany:
+		res = f.Many
+	}
+	if res == "" {
+		return f.Other
+	}
+	return res
+}
+
+// Locale formats dates & numbers, see LocaleEnGb & LocaleCy.
+type Locale struct {
+	// i.e. "en-GB".
+	Tag         string
+	Months      [12]string
+	ShortMonths [12]string
+	// Starting with Sunday, like time.Weekday.
+	Days      [7]string
+	ShortDays [7]string
+	// OrdinalSuffix returns i.e. "nd" for 2.
+	OrdinalSuffix      func(n int) string
+	PluralCategory     func(n int) PluralCategory
+	DecimalSeparator   string
+	ThousandsSeparator string
+	// i.e. "£", it is placed before the number.
+	CurrencySymbol string
+}
+
+var LocaleEnGb = &Locale{
+	Tag: "en-GB",
+	Months: [12]string{
+		"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December",
+	},
+	ShortMonths: [12]string{
+		"Jan", "Feb", "Mar", "Apr", "May", "Jun",
+		"Jul", "Aug", "Sep", "Oct", "Nov", "Dec",
+	},
+	Days:      [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+	ShortDays: [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	OrdinalSuffix: func(n int) string {
+		switch n % 100 {
+		case 11, 12, 13:
+			return "th"
+		}
+		switch n % 10 {
+		case 1:
+			return "st"
+		case 2:
+			return "nd"
+		case 3:
+			return "rd"
+		}
+		return "th"
+	},
+	PluralCategory: func(n int) PluralCategory {
+		if n == 1 {
+			return PluralOne
+		}
+		return PluralOther
+	},
+	DecimalSeparator:   ".",
+	ThousandsSeparator: ",",
+	CurrencySymbol:     "£",
+}
+
+var LocaleCy = &Locale{
+	Tag: "cy",
+	Months: [12]string{
+		"Ionawr", "Chwefror", "Mawrth", "Ebrill", "Mai", "Mehefin",
+		"Gorffennaf", "Awst", "Medi", "Hydref", "Tachwedd", "Rhagfyr",
+	},
+	ShortMonths: [12]string{
+		"Ion", "Chwef", "Maw", "Ebr", "Mai", "Meh",
+		"Gorff", "Awst", "Medi", "Hyd", "Tach", "Rhag",
+	},
+	Days:      [7]string{"Dydd Sul", "Dydd Llun", "Dydd Mawrth", "Dydd Mercher", "Dydd Iau", "Dydd Gwener", "Dydd Sadwrn"},
+	ShortDays: [7]string{"Sul", "Llun", "Maw", "Mer", "Iau", "Gwen", "Sad"},
+	OrdinalSuffix: func(n int) string {
+		switch n {
+		case 1:
+			return "af"
+		case 2:
+			return "il"
+		case 3, 4:
+			return "ydd"
+		case 5, 6:
+			return "ed"
+		case 11, 13, 14, 16, 17, 19:
+			return "eg"
+		}
+		if n <= 20 || n == 40 || n == 50 || n == 60 || n == 80 || n == 100 {
+			return "fed"
+		}
+		return "ain"
+	},
+	PluralCategory: func(n int) PluralCategory {
+		switch n {
+		case 0:
+			return PluralZero
+		case 1:
+			return PluralOne
+		case 2:
+			return PluralTwo
+		case 3:
+			return PluralFew
+		case 6:
+			return PluralMany
+		}
+		return PluralOther
+	},
+	DecimalSeparator:   ".",
+	ThousandsSeparator: ",",
+	CurrencySymbol:     "£",
+}
+
+// Locales are the built-in locales by tag.
+var Locales = map[string]*Locale{
+	LocaleEnGb.Tag: LocaleEnGb,
+	LocaleCy.Tag:   LocaleCy,
+}
+
+// LocaleFor returns the locale for the tag (i.e. "cy-GB" or "cy"), falling
+// back to the language & then LocaleEnGb.
+func LocaleFor(tag string) *Locale {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	for k, l := range Locales {
+		if strings.EqualFold(k, tag) {
+			return l
+		}
+	}
+	lang, _, _ := strings.Cut(tag, "-")
+	for k, l := range Locales {
+		if strings.EqualFold(k, lang) {
+			return l
+		}
+	}
+	return LocaleEnGb
+}
+
+var localeCtxKey = &contextKey{"locale"}
+
+func ContextWithLocale(ctx context.Context, locale *Locale) context.Context {
+	return context.WithValue(ctx, localeCtxKey, locale)
+}
+
+// LocaleForContext returns LocaleEnGb if the context has no locale.
+func LocaleForContext(ctx context.Context) *Locale {
+	if l, ok := ctx.Value(localeCtxKey).(*Locale); ok {
+		return l
+	}
+	return LocaleEnGb
+}
+
+// Ordinal returns i.e. "2nd" (en-GB) or "2il" (cy).
+func (l *Locale) Ordinal(n int) string {
+	return strconv.Itoa(n) + l.OrdinalSuffix(n)
+}
+
+// Plural returns the form for the count.
+func (l *Locale) Plural(count int, forms PluralForms) string {
+	return forms.For(l.PluralCategory(count))
+}
+
+// PluralOf is Plural with the forms as a list, see NewPluralForms, i.e.
+//
+//	{{(locale "cy").PluralOf .Count "" "ci" "gi" "chi" "chi" "ci"}}
+func (l *Locale) PluralOf(count int, forms ...string) string {
+	return l.Plural(count, NewPluralForms(forms...))
+}
+
+// FormatDate is like time.Format with localised month & day names, it also
+// supports ordinal days i.e. 'Monday 2nd January 2006 15:04:05'.
+func (l *Locale) FormatDate(t time.Time, format string) string {
+	// Split the format so the names are not interpreted by time.Format.
+	sb := strings.Builder{}
+	start := 0
+	for i := 0; i < len(format); {
+		value := ""
+		length := 0
+		switch {
+		case strings.HasPrefix(format[i:], "January"):
+			value, length = l.Months[t.Month()-1], 7
+		case strings.HasPrefix(format[i:], "Monday"):
+			value, length = l.Days[t.Weekday()], 6
+		case strings.HasPrefix(format[i:], "Jan"):
+			value, length = l.ShortMonths[t.Month()-1], 3
+		case strings.HasPrefix(format[i:], "Mon"):
+			value, length = l.ShortDays[t.Weekday()], 3
+		case strings.HasPrefix(format[i:], "2nd"):
+			value, length = l.Ordinal(t.Day()), 3
+		default:
+			i++
+			continue
+		}
+		sb.WriteString(t.Format(format[start:i]))
+		sb.WriteString(value)
+		i += length
+		start = i
+	}
+	sb.WriteString(t.Format(format[start:]))
+	return sb.String()
+}
+
+// FormatDecimal formats with thousands separators, rounded to places.
+func (l *Locale) FormatDecimal(d decimal.Decimal, places int32) string {
+	str := d.Abs().StringFixed(places)
+	intPart, fracPart, _ := strings.Cut(str, ".")
+
+	sb := strings.Builder{}
+	if d.Round(places).IsNegative() {
+		sb.WriteString("-")
+	}
+	for i, c := range intPart {
+		if i != 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteString(l.ThousandsSeparator)
+		}
+		sb.WriteRune(c)
+	}
+	if fracPart != "" {
+		sb.WriteString(l.DecimalSeparator)
+		sb.WriteString(fracPart)
+	}
+	return sb.String()
+}
+
+// FormatCurrency formats i.e. "-£1,234.50".
+func (l *Locale) FormatCurrency(d decimal.Decimal) string {
+	formatted := l.FormatDecimal(d, 2)
+	if strings.HasPrefix(formatted, "-") {
+		return "-" + l.CurrencySymbol + formatted[1:]
+	}
+	return l.CurrencySymbol + formatted
+}
@@ -0,0 +1,159 @@
+package wwgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// RotatingFileWriter writes to a file, renaming it to file.1 (and file.1 to
+// file.2 etc.) when it reaches the max size.
+type RotatingFileWriter struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	mut        sync.Mutex
+}
+
+func NewRotatingFileWriter(path string, maxBytes int64, maxBackups int) (*RotatingFileWriter, error) {
+	if maxBytes == 0 {
+		maxBytes = 100 << 20
+	}
+	if maxBackups == 0 {
+		maxBackups = 3
+	}
+	w := &RotatingFileWriter{
+		path:       path,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if w.size+int64(len(p)) > w.maxBytes && w.size != 0 {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *RotatingFileWriter) Close() error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	return w.file.Close()
+}
+
+func (w *RotatingFileWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", w.path)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return errors.Wrapf(err, "failed to stat %s", w.path)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+func (w *RotatingFileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close %s", w.path)
+	}
+	for i := w.maxBackups - 1; i > 0; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+	}
+	if err := os.Rename(w.path, w.path+".1"); err != nil {
+		return errors.Wrapf(err, "failed to rotate %s", w.path)
+	}
+	return w.open()
+}
+
+// RedactWriter replaces the values of the given fields in JSON log lines
+// with "***". It must be given the JSON output of zerolog, so wrap any
+// ConsoleWriter with it rather than the other way round.
+type RedactWriter struct {
+	out    io.Writer
+	fields map[string]bool
+}
+
+func NewRedactWriter(out io.Writer, fields []string) *RedactWriter {
+	w := &RedactWriter{
+		out:    out,
+		fields: map[string]bool{},
+	}
+	for _, f := range fields {
+		w.fields[strings.ToLower(f)] = true
+	}
+	return w
+}
+
+func (w *RedactWriter) Write(p []byte) (int, error) {
+	// Only decode the line if it might contain a redacted field.
+	lower := bytes.ToLower(p)
+	found := false
+	for f := range w.fields {
+		if bytes.Contains(lower, []byte(`"`+f+`"`)) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return w.out.Write(p)
+	}
+
+	var entry map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(p))
+	d.UseNumber()
+	if err := d.Decode(&entry); err != nil {
+		// Not JSON, leave it alone.
+		return w.out.Write(p)
+	}
+	redacted, err := json.Marshal(w.redact(entry))
+	if err != nil {
+		return w.out.Write(p)
+	}
+	if _, err := w.out.Write(append(redacted, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *RedactWriter) redact(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, vv := range v {
+			if w.fields[strings.ToLower(k)] {
+				v[k] = "***"
+			} else {
+				v[k] = w.redact(vv)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, vv := range v {
+			v[i] = w.redact(vv)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -1,32 +1,176 @@
 package wwgo
 
 import (
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/pkgerrors"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 )
 
+type LoggerOpt struct {
+	Level zerolog.Level
+	// Pretty, human-readable output for local dev, otherwise JSON.
+	Console bool
+	// Optional, log to this file instead of stderr.
+	File string
+	// Optional, rotate the file when it reaches this size (default 100MB).
+	FileMaxBytes int64
+	// Optional, the number of rotated files to keep (default 3).
+	FileMaxBackups int
+	// Optional, only log 1 in every N messages for the level, 0 is no sampling.
+	SampleEvery map[zerolog.Level]uint32
+	// Optional, the values of these fields are replaced with "***" (matched
+	// case-insensitively at any depth) i.e. "email", "token".
+	RedactFields []string
+}
+
+// LoggerOptFromEnv reads the logger options from:
+// - LOG_LEVEL: TRACE, DEBUG, INFO (default), WARN, ERROR, FATAL, PANIC or DISABLED
+// - LOG_FORMAT: JSON (default) or CONSOLE
+// - LOG_FILE, LOG_FILE_MAX_MB & LOG_FILE_MAX_BACKUPS
+// - LOG_SAMPLE_TRACE, LOG_SAMPLE_DEBUG & LOG_SAMPLE_INFO: i.e. 10 to log 1 in 10
+// - LOG_REDACT_FIELDS: a comma separated list of field names
+// Every invalid value is returned in a *MultiError, the defaults are used for
+// them so the options are still usable.
+func LoggerOptFromEnv() (LoggerOpt, error) {
+	opt := LoggerOpt{
+		Level:        zerolog.InfoLevel,
+		File:         os.Getenv("LOG_FILE"),
+		RedactFields: SplitTrimAndFilterString(os.Getenv("LOG_REDACT_FIELDS"), ","),
+		SampleEvery:  map[zerolog.Level]uint32{},
+	}
+
+	var errs []error
+	if logLevelStr := os.Getenv("LOG_LEVEL"); logLevelStr != "" {
+		level, err := parseLogLevel(logLevelStr)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			opt.Level = level
+		}
+	}
+
+	switch strings.ToUpper(os.Getenv("LOG_FORMAT")) {
+	case "", "JSON":
+	case "CONSOLE", "PRETTY":
+		opt.Console = true
+	default:
+		errs = append(errs, errors.Errorf("Unsupported LOG_FORMAT %s", os.Getenv("LOG_FORMAT")))
+	}
+
+	if v := os.Getenv("LOG_FILE_MAX_MB"); v != "" {
+		maxMb, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "invalid LOG_FILE_MAX_MB"))
+		} else {
+			opt.FileMaxBytes = maxMb << 20
+		}
+	}
+	if v := os.Getenv("LOG_FILE_MAX_BACKUPS"); v != "" {
+		maxBackups, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "invalid LOG_FILE_MAX_BACKUPS"))
+		} else {
+			opt.FileMaxBackups = maxBackups
+		}
+	}
+
+	for _, level := range []zerolog.Level{zerolog.TraceLevel, zerolog.DebugLevel, zerolog.InfoLevel} {
+		envName := "LOG_SAMPLE_" + strings.ToUpper(level.String())
+		if v := os.Getenv(envName); v != "" {
+			n, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "invalid %s", envName))
+			} else if n < 1 {
+				errs = append(errs, errors.Errorf("invalid %s, it must be at least 1", envName))
+			} else {
+				opt.SampleEvery[level] = uint32(n)
+			}
+		}
+	}
+
+	if len(errs) != 0 {
+		return opt, &MultiError{errs: errs}
+	}
+	return opt, nil
+}
+
 func NewDefaultLogger() zerolog.Logger {
+	opt, optErr := LoggerOptFromEnv()
+	logger, err := NewLogger(opt)
+	if err != nil {
+		// Fallback to stderr so we can at least report it.
+		opt.File = ""
+		logger, _ = NewLogger(opt)
+		logger.Err(err).Msg("Failed to open log file")
+	}
+	if optErr != nil {
+		logger.Error().Msg(optErr.Error())
+	}
+	return logger
+}
+
+func NewLogger(opt LoggerOpt) (zerolog.Logger, error) {
 	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
-	logger := zerolog.New(os.Stderr).With().Timestamp().Caller().Logger()
-
-	// Set log level from env.
-	logLevel := zerolog.InfoLevel
-	logLevelStr := os.Getenv("LOG_LEVEL")
-	if logLevelStr != "" {
-		switch strings.ToUpper(logLevelStr) {
-		case "TRACE":
-			logLevel = zerolog.TraceLevel
-		case "DEBUG":
-			logLevel = zerolog.DebugLevel
-		case "INFO":
-			logLevel = zerolog.InfoLevel
-		default:
-			logger.Error().Msgf("Unsupported LOG_LEVEL %s", logLevelStr)
+
+	// Output.
+	var w io.Writer = os.Stderr
+	if opt.File != "" {
+		fileWriter, err := NewRotatingFileWriter(opt.File, opt.FileMaxBytes, opt.FileMaxBackups)
+		if err != nil {
+			return zerolog.Nop(), err
+		}
+		w = fileWriter
+	}
+	if opt.Console {
+		w = zerolog.ConsoleWriter{Out: w, NoColor: opt.File != ""}
+	}
+	if len(opt.RedactFields) != 0 {
+		w = NewRedactWriter(w, opt.RedactFields)
+	}
+
+	logger := zerolog.New(w).With().Timestamp().Caller().Logger().Level(opt.Level)
+
+	// Sampling.
+	if len(opt.SampleEvery) != 0 {
+		sampler := zerolog.LevelSampler{}
+		for level, n := range opt.SampleEvery {
+			// BasicSampler would divide by zero.
+			if n == 0 {
+				continue
+			}
+			switch level {
+			case zerolog.TraceLevel:
+				sampler.TraceSampler = &zerolog.BasicSampler{N: n}
+			case zerolog.DebugLevel:
+				sampler.DebugSampler = &zerolog.BasicSampler{N: n}
+			case zerolog.InfoLevel:
+				sampler.InfoSampler = &zerolog.BasicSampler{N: n}
+			case zerolog.WarnLevel:
+				sampler.WarnSampler = &zerolog.BasicSampler{N: n}
+			case zerolog.ErrorLevel:
+				sampler.ErrorSampler = &zerolog.BasicSampler{N: n}
+			}
 		}
+		logger = logger.Sample(sampler)
 	}
-	logger = logger.Level(logLevel)
 
-	return logger
+	return logger, nil
+}
+
+func parseLogLevel(str string) (zerolog.Level, error) {
+	switch strings.ToUpper(str) {
+	case "WARNING":
+		return zerolog.WarnLevel, nil
+	case "DISABLED", "OFF":
+		return zerolog.Disabled, nil
+	}
+	level, err := zerolog.ParseLevel(strings.ToLower(str))
+	if err != nil || level == zerolog.NoLevel {
+		return zerolog.InfoLevel, errors.Errorf("Unsupported LOG_LEVEL %s", str)
+	}
+	return level, nil
 }
@@ -0,0 +1,54 @@
+package wwgo
+
+import (
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"strings"
+	"testing"
+)
+
+func TestLoggerOptFromEnv(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "warning")
+	t.Setenv("LOG_FORMAT", "console")
+	t.Setenv("LOG_FILE_MAX_MB", "5")
+	t.Setenv("LOG_SAMPLE_DEBUG", "10")
+
+	opt, err := LoggerOptFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opt.Level != zerolog.WarnLevel || !opt.Console || opt.FileMaxBytes != 5<<20 || opt.SampleEvery[zerolog.DebugLevel] != 10 {
+		t.Fatalf("unexpected options %+v", opt)
+	}
+}
+
+func TestLoggerOptFromEnvErrors(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "loud")
+	t.Setenv("LOG_FORMAT", "xml")
+	t.Setenv("LOG_FILE_MAX_MB", "lots")
+	t.Setenv("LOG_FILE_MAX_BACKUPS", "3")
+	t.Setenv("LOG_SAMPLE_INFO", "0")
+
+	opt, err := LoggerOptFromEnv()
+	var multiErr *MultiError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("expected a MultiError, got %v", err)
+	}
+	msgs := JoinErrors(multiErr.Errors(), "\n")
+	if len(multiErr.Errors()) != 4 {
+		t.Fatalf("expected 4 errors, got:\n%s", msgs)
+	}
+	for _, expected := range []string{"LOG_LEVEL", "LOG_FORMAT", "LOG_FILE_MAX_MB", "LOG_SAMPLE_INFO"} {
+		if !strings.Contains(msgs, expected) {
+			t.Errorf("expected '%s' in:\n%s", expected, msgs)
+		}
+	}
+
+	// The invalid values use the defaults & the valid ones are still read.
+	if opt.Level != zerolog.InfoLevel || opt.Console || opt.FileMaxBytes != 0 || len(opt.SampleEvery) != 0 {
+		t.Fatalf("expected the defaults for the invalid values, got %+v", opt)
+	}
+	if opt.FileMaxBackups != 3 {
+		t.Fatalf("expected LOG_FILE_MAX_BACKUPS to be read, got %d", opt.FileMaxBackups)
+	}
+}
@@ -1,51 +1,152 @@
 package wwgo
 
 import (
+	"context"
 	"github.com/beevik/ntp"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
+	"sort"
 	"sync"
 	"time"
 )
 
+type NtpTimeOpt struct {
+	// i.e. "0.uk.pool.ntp.org" or "localhost:1123".
+	Servers []string
+	// Optional, how often to query the servers (default 1 hour).
+	RefreshInterval time.Duration
+	// Optional, the timeout for each query (default 5 seconds).
+	Timeout time.Duration
+	// Optional, offsets further than this from the median are ignored (default
+	// 1 second).
+	MaxOutlier time.Duration
+	// Optional, a warning is logged if the offset changes by more than this
+	// between refreshes (default 100ms).
+	DriftWarning time.Duration
+}
+
 type NtpTime struct {
 	log            zerolog.Logger
-	ntpServer      string
+	opt            NtpTimeOpt
 	mut            sync.RWMutex
 	timeOffset     time.Duration
 	lastNtpRefresh time.Time
+	lastAttempt    time.Time
+	refreshing     bool
+	background     bool
+	firstRefresh   sync.Once
 }
 
 func NewNtpTime(log zerolog.Logger, ntpServer string) (*NtpTime, error) {
 	if ntpServer == "" {
 		return nil, errors.Errorf("ntpServer is empty")
 	}
+	return NewNtpTimeWithOpt(log, NtpTimeOpt{Servers: []string{ntpServer}})
+}
+
+func NewNtpTimeWithOpt(log zerolog.Logger, opt NtpTimeOpt) (*NtpTime, error) {
+	opt.Servers = ArrayFilterStr(opt.Servers)
+	if len(opt.Servers) == 0 {
+		return nil, errors.Errorf("no NTP servers")
+	}
+	if opt.RefreshInterval == 0 {
+		opt.RefreshInterval = time.Hour
+	}
+	if opt.Timeout == 0 {
+		opt.Timeout = 5 * time.Second
+	}
+	if opt.MaxOutlier == 0 {
+		opt.MaxOutlier = time.Second
+	}
+	if opt.DriftWarning == 0 {
+		opt.DriftWarning = 100 * time.Millisecond
+	}
 
 	return &NtpTime{
-		log:       log,
-		ntpServer: ntpServer,
+		log: log,
+		opt: opt,
 	}, nil
 }
 
-func (nt *NtpTime) GetTimeAndOffset() (time.Time, time.Duration) {
+// StartRefresh refreshes the offset in the background until the context is
+// cancelled, so that requests never have to wait for NTP.
+func (nt *NtpTime) StartRefresh(ctx context.Context) {
 	nt.mut.Lock()
-	defer nt.mut.Unlock()
+	nt.background = true
+	nt.mut.Unlock()
+
+	go func() {
+		defer func() {
+			nt.mut.Lock()
+			nt.background = false
+			nt.mut.Unlock()
+		}()
+
+		nt.initialRefresh()
+		ticker := time.NewTicker(nt.opt.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			nt.refresh(ctx)
+		}
+	}()
+}
+
+// GetTimeAndOffset waits for the first refresh (up to the Timeout), after that
+// it never blocks on NTP. If the offset is stale and StartRefresh is not
+// running, it is refreshed in the background. Until a refresh succeeds local
+// time is used, with a warning.
+func (nt *NtpTime) GetTimeAndOffset() (time.Time, time.Duration) {
+	nt.initialRefresh()
 
-	// Do not refresh if it's been less than an hour.
+	nt.mut.Lock()
 	now := time.Now()
-	if now.Sub(nt.lastNtpRefresh) < time.Hour {
-		return now.Add(nt.timeOffset), nt.timeOffset
+	needsRefresh := !nt.background && !nt.refreshing && now.Sub(nt.lastAttempt) >= nt.opt.RefreshInterval
+	if needsRefresh {
+		// Stop other calls starting a refresh before this one has.
+		nt.lastAttempt = now
+	}
+	offset := nt.timeOffset
+	hasOffset := !nt.lastNtpRefresh.IsZero()
+	nt.mut.Unlock()
+
+	if !hasOffset {
+		nt.log.Warn().Msg("NTP time is unavailable, using local time")
 	}
 
-	// Get latest NTP time.
-	resp, err := ntp.Query(nt.ntpServer)
-	if err != nil {
-		panic(errors.Wrapf(err, "Failed to get NTP time from %s", nt.ntpServer))
+	if needsRefresh {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), nt.opt.Timeout)
+			defer cancel()
+			nt.refresh(ctx)
+		}()
+	}
+
+	return now.Add(offset), offset
+}
+
+// initialRefresh refreshes once, concurrent calls wait for it.
+func (nt *NtpTime) initialRefresh() {
+	nt.firstRefresh.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), nt.opt.Timeout)
+		defer cancel()
+		nt.refresh(ctx)
+	})
+}
+
+// refresh logs rather than returning the error.
+func (nt *NtpTime) refresh(ctx context.Context) {
+	if err := nt.Refresh(ctx); err != nil {
+		if nt.LastRefresh().IsZero() {
+			nt.log.Warn().Err(err).Msg("Failed to refresh NTP time, using local time")
+		} else {
+			nt.log.Warn().Err(err).Msg("Failed to refresh NTP time, using last known offset")
+		}
 	}
-	nt.log.Debug().Dur("offset", resp.ClockOffset).Msgf("Got NTP time from %s", nt.ntpServer)
-	nt.timeOffset = resp.ClockOffset
-	nt.lastNtpRefresh = now
-	return resp.Time, resp.ClockOffset
 }
 
 func (nt *NtpTime) GetTime() time.Time {
@@ -57,3 +158,120 @@ func (nt *NtpTime) GetTimeOffset() time.Duration {
 	_, offset := nt.GetTimeAndOffset()
 	return offset
 }
+
+// LastRefresh returns when the offset was last successfully updated, or the
+// zero time if it never has.
+func (nt *NtpTime) LastRefresh() time.Time {
+	nt.mut.RLock()
+	defer nt.mut.RUnlock()
+	return nt.lastNtpRefresh
+}
+
+// Refresh queries all the servers and updates the offset to the median,
+// ignoring outliers. It only fails if none of the servers respond.
+func (nt *NtpTime) Refresh(ctx context.Context) error {
+	nt.mut.Lock()
+	if nt.refreshing {
+		nt.mut.Unlock()
+		return nil
+	}
+	nt.refreshing = true
+	nt.lastAttempt = time.Now()
+	nt.mut.Unlock()
+	defer func() {
+		nt.mut.Lock()
+		nt.refreshing = false
+		nt.mut.Unlock()
+	}()
+
+	// Query all servers at once.
+	offsets := make([]*time.Duration, len(nt.opt.Servers))
+	errs := make([]error, len(nt.opt.Servers))
+	wg := sync.WaitGroup{}
+	for i, server := range nt.opt.Servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			offset, err := nt.query(ctx, server)
+			if err != nil {
+				errs[i] = err
+				nt.log.Warn().Err(err).Msgf("Failed to get NTP time from %s", server)
+				return
+			}
+			nt.log.Debug().Dur("offset", offset).Msgf("Got NTP time from %s", server)
+			offsets[i] = &offset
+		}()
+	}
+	wg.Wait()
+
+	found := DerefPtrSlice(FilterSlice(offsets, func(v *time.Duration) bool { return v != nil }))
+	if len(found) == 0 {
+		return &MultiError{errs: FilterSlice(errs, func(err error) bool { return err != nil })}
+	}
+	offset := medianOffset(found, nt.opt.MaxOutlier)
+
+	// Update & check for drift.
+	nt.mut.Lock()
+	previous := nt.timeOffset
+	hadOffset := !nt.lastNtpRefresh.IsZero()
+	nt.timeOffset = offset
+	nt.lastNtpRefresh = time.Now()
+	nt.mut.Unlock()
+
+	drift := offset - previous
+	logEvt := nt.log.Debug()
+	if hadOffset && (drift > nt.opt.DriftWarning || -drift > nt.opt.DriftWarning) {
+		logEvt = nt.log.Warn()
+	}
+	logEvt.Dur("offset", offset).Dur("drift", drift).Int("servers", len(found)).Msg("NTP offset updated")
+	return nil
+}
+
+func (nt *NtpTime) query(ctx context.Context, server string) (time.Duration, error) {
+	type result struct {
+		resp *ntp.Response
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		resp, err := ntp.QueryWithOptions(server, ntp.QueryOptions{Timeout: nt.opt.Timeout})
+		ch <- result{resp: resp, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case res := <-ch:
+		if res.err != nil {
+			return 0, errors.Wrapf(res.err, "failed to query %s", server)
+		}
+		if err := res.resp.Validate(); err != nil {
+			return 0, errors.Wrapf(err, "invalid response from %s", server)
+		}
+		return res.resp.ClockOffset, nil
+	}
+}
+
+// medianOffset returns the median, after discarding any offsets further than
+// maxOutlier from the initial median.
+func medianOffset(offsets []time.Duration, maxOutlier time.Duration) time.Duration {
+	median := func(s []time.Duration) time.Duration {
+		sorted := append([]time.Duration{}, s...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		mid := len(sorted) / 2
+		if len(sorted)%2 == 0 {
+			return (sorted[mid-1] + sorted[mid]) / 2
+		}
+		return sorted[mid]
+	}
+
+	m := median(offsets)
+	kept := FilterSlice(offsets, func(v time.Duration) bool {
+		d := v - m
+		return d <= maxOutlier && -d <= maxOutlier
+	})
+	if len(kept) == 0 {
+		return m
+	}
+	return median(kept)
+}
@@ -0,0 +1,125 @@
+package wwgo
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/rs/zerolog"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeNtpServer responds to NTP queries on localhost with the local time plus
+// the offset, or not at all if silent.
+func fakeNtpServer(t *testing.T, offset time.Duration, silent bool) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, 48)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < 48 || silent {
+				continue
+			}
+			now := toNtpTime(time.Now().Add(offset))
+			resp := make([]byte, 48)
+			resp[0] = 4<<3 | 4 // Version 4, server mode.
+			resp[1] = 1        // Stratum.
+			resp[3] = 0xec     // Precision.
+			binary.BigEndian.PutUint64(resp[16:], now)
+			// The origin time is the request's transmit time.
+			copy(resp[24:32], buf[40:48])
+			binary.BigEndian.PutUint64(resp[32:], now)
+			binary.BigEndian.PutUint64(resp[40:], now)
+			_, _ = conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func toNtpTime(t time.Time) uint64 {
+	const ntpEpochOffset = 2208988800
+	nsec := uint64(t.Sub(time.Unix(-ntpEpochOffset, 0)))
+	sec := nsec / 1e9
+	frac := (nsec % 1e9) << 32 / 1e9
+	return sec<<32 | frac
+}
+
+func assertNtpOffset(t *testing.T, got time.Duration, want time.Duration) {
+	t.Helper()
+	if d := got - want; d > 50*time.Millisecond || d < -50*time.Millisecond {
+		t.Fatalf("expected offset ~%s, got %s", want, got)
+	}
+}
+
+func TestNtpTimeMedian(t *testing.T) {
+	nt, err := NewNtpTimeWithOpt(zerolog.Nop(), NtpTimeOpt{
+		Servers: []string{
+			fakeNtpServer(t, 2*time.Second, false),
+			fakeNtpServer(t, 2100*time.Millisecond, false),
+			// Outlier.
+			fakeNtpServer(t, 30*time.Second, false),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := nt.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	assertNtpOffset(t, nt.GetTimeOffset(), 2050*time.Millisecond)
+}
+
+func TestNtpTimeFirstCallWaitsForRefresh(t *testing.T) {
+	nt, err := NewNtpTimeWithOpt(zerolog.Nop(), NtpTimeOpt{
+		Servers: []string{fakeNtpServer(t, 3*time.Second, false)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNtpOffset(t, nt.GetTimeOffset(), 3*time.Second)
+	if nt.LastRefresh().IsZero() {
+		t.Fatal("expected the first call to refresh")
+	}
+}
+
+func TestNtpTimeUnavailable(t *testing.T) {
+	nt, err := NewNtpTimeWithOpt(zerolog.Nop(), NtpTimeOpt{
+		Servers:         []string{fakeNtpServer(t, 0, true)},
+		Timeout:         100 * time.Millisecond,
+		RefreshInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first call waits for the timeout, then uses local time.
+	start := time.Now()
+	if offset := nt.GetTimeOffset(); offset != 0 {
+		t.Fatalf("expected local time, got offset %s", offset)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("expected the first refresh to be bounded by the timeout")
+	}
+
+	// Later refreshes are in the background.
+	time.Sleep(20 * time.Millisecond)
+	start = time.Now()
+	nt.GetTime()
+	if time.Since(start) > 50*time.Millisecond {
+		t.Fatal("expected GetTime not to wait for a stale refresh")
+	}
+	// Let the background refresh time out.
+	time.Sleep(300 * time.Millisecond)
+	if err := nt.Refresh(context.Background()); err == nil {
+		t.Fatal("expected an error from an unresponsive server")
+	}
+}
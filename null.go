@@ -0,0 +1,170 @@
+package wwgo
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"io"
+	"time"
+)
+
+// Null is a nullable value that can be used from the DB row through to the
+// GraphQL response, replacing the SqlNull* & *FromSql helpers.
+//
+// Zero values are only treated as null when created with NullIfZero, i.e.
+// NullIfZero("") is null but NewNull("") is a valid empty string.
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+// NewNull returns a valid (non-null) value.
+func NewNull[T any](v T) Null[T] {
+	return Null[T]{V: v, Valid: true}
+}
+
+// NullFromPtr returns null if the pointer is nil.
+func NullFromPtr[T any](v *T) Null[T] {
+	if v == nil {
+		return Null[T]{}
+	}
+	return NewNull(*v)
+}
+
+// NullIfZero returns null if the value is the zero value, or has an IsZero()
+// method that returns true (i.e. time.Time & decimal.Decimal).
+func NullIfZero[T comparable](v T) Null[T] {
+	if z, ok := any(v).(interface{ IsZero() bool }); ok {
+		return Null[T]{V: v, Valid: !z.IsZero()}
+	}
+	var zero T
+	return Null[T]{V: v, Valid: v != zero}
+}
+
+// Ptr returns nil if null.
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	return ToPtr(n.V)
+}
+
+// ValueOr returns def if null.
+func (n Null[T]) ValueOr(def T) T {
+	if !n.Valid {
+		return def
+	}
+	return n.V
+}
+
+func (n *Null[T]) Scan(src any) error {
+	if src == nil {
+		*n = Null[T]{}
+		return nil
+	}
+	// Use the value's own Scanner if it has one (i.e. uuid.UUID).
+	if scanner, ok := any(&n.V).(sql.Scanner); ok {
+		if err := scanner.Scan(src); err != nil {
+			return err
+		}
+		n.Valid = true
+		return nil
+	}
+	sn := sql.Null[T]{}
+	if err := sn.Scan(src); err != nil {
+		return err
+	}
+	n.V, n.Valid = sn.V, sn.Valid
+	return nil
+}
+
+func (n Null[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(n.V)
+}
+
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.V)
+}
+
+func (n *Null[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*n = Null[T]{}
+		return nil
+	}
+	if err := json.Unmarshal(data, &n.V); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// MarshalGQL implements the gqlgen Marshaler, the value's own MarshalGQL is
+// used if it has one, otherwise it is encoded as JSON (times use GqlTime).
+func (n Null[T]) MarshalGQL(w io.Writer) {
+	if !n.Valid {
+		_, _ = w.Write([]byte("null"))
+		return
+	}
+	switch v := any(n.V).(type) {
+	case interface{ MarshalGQL(w io.Writer) }:
+		v.MarshalGQL(w)
+		return
+	case time.Time:
+		_, _ = w.Write([]byte(`"` + GqlTime(v) + `"`))
+		return
+	}
+	encoded, err := json.Marshal(n.V)
+	if err != nil {
+		panic(errors.Wrapf(err, "failed to marshal %T", n.V))
+	}
+	_, _ = w.Write(encoded)
+}
+
+// UnmarshalGQL implements the gqlgen Unmarshaler.
+func (n *Null[T]) UnmarshalGQL(v interface{}) error {
+	if v == nil {
+		*n = Null[T]{}
+		return nil
+	}
+	if u, ok := any(&n.V).(interface{ UnmarshalGQL(v interface{}) error }); ok {
+		if err := u.UnmarshalGQL(v); err != nil {
+			return err
+		}
+		n.Valid = true
+		return nil
+	}
+
+	// Round trip through JSON to convert i.e. json.Number to int.
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "invalid value %T", v)
+	}
+	if err := json.Unmarshal(encoded, &n.V); err != nil {
+		return errors.Wrapf(err, "invalid value for %T", n.V)
+	}
+	n.Valid = true
+	return nil
+}
+
+// Aliases for use in gqlgen.yml, which does not support generic types.
+type (
+	NullString  = Null[string]
+	NullInt     = Null[int]
+	NullInt32   = Null[int32]
+	NullInt64   = Null[int64]
+	NullFloat64 = Null[float64]
+	NullBool    = Null[bool]
+	NullTime    = Null[time.Time]
+	NullUuid    = Null[uuid.UUID]
+	NullDecimal = Null[decimal.Decimal]
+)
@@ -0,0 +1,360 @@
+package wwgo
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh/terminal"
+	"io"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PromptAnswerEnvPrefix is prepended to the upper-cased Question.Name to look
+// up answers from the environment, i.e. PROMPT_ANSWER_DROP=y.
+const PromptAnswerEnvPrefix = "PROMPT_ANSWER_"
+
+// Question is asked by a Prompter.
+type Question struct {
+	// Optional, used to look up answers in non-interactive mode.
+	Name    string
+	Message string
+	// Optional, shown & used if the answer is blank.
+	Default string
+	// Optional, if true a blank answer (without a default) is rejected.
+	Required bool
+	// Optional.
+	Validate func(answer string) error
+}
+
+// Prompter asks questions on any reader & writer. In non-interactive mode,
+// answers are taken from Answers or the environment (see
+// PromptAnswerEnvPrefix), falling back to the default.
+type Prompter struct {
+	in  *bufio.Reader
+	fd  int
+	out io.Writer
+	// Optional, answers by Question.Name, these are used even when interactive.
+	Answers map[string]string
+	// Optional, if true an error is returned for questions without an answer
+	// or default instead of reading from the input.
+	NonInteractive bool
+}
+
+// NewPrompter creates a Prompter, passwords are only hidden if in is a
+// terminal.
+func NewPrompter(in io.Reader, out io.Writer) *Prompter {
+	p := &Prompter{
+		in:  bufio.NewReader(in),
+		fd:  -1,
+		out: out,
+	}
+	if f, ok := in.(*os.File); ok && terminal.IsTerminal(int(f.Fd())) {
+		p.fd = int(f.Fd())
+	}
+	return p
+}
+
+// stdPrompter is shared by the std Prompters, as the buffered stdin would
+// otherwise be lost between them when it is piped.
+var stdPrompter = sync.OnceValue(func() *Prompter {
+	return NewPrompter(os.Stdin, os.Stdout)
+})
+
+// NewStdPrompter creates a Prompter for stdin & stdout, the input buffer is
+// shared with the Cli* functions & other std Prompters.
+func NewStdPrompter() *Prompter {
+	p := *stdPrompter()
+	return &p
+}
+
+// PromptFlags adds --non-interactive & --answer name=value flags, see
+// PrompterFromCli.
+func PromptFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "non-interactive",
+			Usage:   "Do not prompt, use --answer, " + PromptAnswerEnvPrefix + "* env vars or defaults",
+			EnvVars: []string{"NON_INTERACTIVE"},
+		},
+		&cli.StringSliceFlag{
+			Name:  "answer",
+			Usage: "Answer a prompt i.e. --answer drop=y",
+		},
+	}
+}
+
+// PrompterFromCli creates a stdin/stdout Prompter with the PromptFlags.
+func PrompterFromCli(ctx *cli.Context) (*Prompter, error) {
+	p := NewStdPrompter()
+	p.NonInteractive = ctx.Bool("non-interactive")
+	for _, answer := range ctx.StringSlice("answer") {
+		name, value, ok := strings.Cut(answer, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid --answer '%s', expected name=value", answer)
+		}
+		if p.Answers == nil {
+			p.Answers = map[string]string{}
+		}
+		p.Answers[name] = value
+	}
+	return p, nil
+}
+
+// Ask returns the answer, asking again if it is invalid.
+func (p *Prompter) Ask(q Question) (string, error) {
+	validate := func(answer string) error {
+		if answer == "" {
+			if q.Required {
+				return errors.New("an answer is required")
+			}
+			return nil
+		}
+		if q.Validate != nil {
+			return q.Validate(answer)
+		}
+		return nil
+	}
+
+	// Non-interactive answers.
+	if answer, ok := p.answer(q); ok {
+		name := q.Name
+		if name == "" {
+			name = q.Message
+		}
+		if answer == "" && q.Required {
+			return "", errors.Errorf("'%s' requires an answer in non-interactive mode", name)
+		}
+		if err := validate(answer); err != nil {
+			return "", errors.Wrapf(err, "invalid answer for '%s'", name)
+		}
+		return answer, nil
+	}
+
+	for {
+		p.printf("%s", q.Message)
+		if q.Default != "" {
+			p.printf(" [%s]", q.Default)
+		}
+		p.printf(": ")
+
+		answer, err := p.readLine()
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			answer = q.Default
+		}
+		if err := validate(answer); err != nil {
+			p.printf("%s\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// AskInt returns the answer as an int.
+func (p *Prompter) AskInt(q Question) (int, error) {
+	q.Validate = chainValidators(func(answer string) error {
+		if _, err := strconv.Atoi(answer); err != nil {
+			return errors.Errorf("'%s' is not a whole number", answer)
+		}
+		return nil
+	}, q.Validate)
+	answer, err := p.Ask(q)
+	if err != nil || answer == "" {
+		return 0, err
+	}
+	return strconv.Atoi(answer)
+}
+
+// AskEmail returns the answer, which must be an email address.
+func (p *Prompter) AskEmail(q Question) (string, error) {
+	q.Validate = chainValidators(ValidateEmail, q.Validate)
+	return p.Ask(q)
+}
+
+// Confirm asks a yes/no question, the default should be "y", "n" or empty.
+func (p *Prompter) Confirm(q Question) (bool, error) {
+	if q.Default == "" {
+		q.Message += " [y/n]"
+	}
+	q.Required = true
+	q.Validate = func(answer string) error {
+		if _, ok := parseYesNo(answer); !ok {
+			return errors.New("please answer y or n")
+		}
+		return nil
+	}
+	answer, err := p.Ask(q)
+	if err != nil {
+		return false, err
+	}
+	yes, _ := parseYesNo(answer)
+	return yes, nil
+}
+
+// Select asks for one of the options, which may be chosen by number or value.
+func (p *Prompter) Select(q Question, options []string) (string, error) {
+	if len(options) == 0 {
+		return "", errors.Errorf("no options for '%s'", q.Message)
+	}
+	toOption := func(answer string) (string, bool) {
+		if i, err := strconv.Atoi(answer); err == nil && i >= 1 && i <= len(options) {
+			return options[i-1], true
+		}
+		for _, o := range options {
+			if strings.EqualFold(o, answer) {
+				return o, true
+			}
+		}
+		return "", false
+	}
+	q.Required = true
+	q.Validate = chainValidators(func(answer string) error {
+		if _, ok := toOption(answer); !ok {
+			return errors.Errorf("'%s' is not one of the options", answer)
+		}
+		return nil
+	}, q.Validate)
+
+	if _, ok := p.answer(q); !ok {
+		for i, o := range options {
+			p.printf("  %d) %s\n", i+1, o)
+		}
+	}
+	answer, err := p.Ask(q)
+	if err != nil {
+		return "", err
+	}
+	option, _ := toOption(answer)
+	return option, nil
+}
+
+// Password asks for a password without echoing it (if the input is a
+// terminal), if confirm is true it must be entered twice.
+func (p *Prompter) Password(q Question, confirm bool) (string, error) {
+	if _, ok := p.answer(q); ok {
+		return p.Ask(q)
+	}
+
+	for {
+		p.printf("%s: ", q.Message)
+		password, err := p.readPassword()
+		if err != nil {
+			return "", err
+		}
+		if password == "" && q.Required {
+			p.printf("a password is required\n")
+			continue
+		}
+		if q.Validate != nil && password != "" {
+			if err := q.Validate(password); err != nil {
+				p.printf("%s\n", err)
+				continue
+			}
+		}
+		if !confirm {
+			return password, nil
+		}
+
+		p.printf("Confirm %s: ", confirmMessage(q.Message))
+		confirmation, err := p.readPassword()
+		if err != nil {
+			return "", err
+		}
+		if confirmation != password {
+			p.printf("passwords do not match\n")
+			continue
+		}
+		return password, nil
+	}
+}
+
+// confirmMessage lower-cases the first letter of the message.
+func confirmMessage(message string) string {
+	if message == "" {
+		return "password"
+	}
+	return strings.ToLower(message[:1]) + message[1:]
+}
+
+// answer returns the non-interactive answer.
+func (p *Prompter) answer(q Question) (string, bool) {
+	if q.Name != "" {
+		if answer, ok := p.Answers[q.Name]; ok {
+			return answer, true
+		}
+		envName := PromptAnswerEnvPrefix + strings.ToUpper(strings.ReplaceAll(q.Name, "-", "_"))
+		if answer, ok := os.LookupEnv(envName); ok {
+			return answer, true
+		}
+	}
+	if p.NonInteractive {
+		return q.Default, true
+	}
+	return "", false
+}
+
+func (p *Prompter) readLine() (string, error) {
+	input, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || input == "") {
+		if err == io.EOF {
+			return "", errors.Wrapf(io.ErrUnexpectedEOF, "no answer")
+		}
+		return "", errors.Wrapf(err, "failed to read answer")
+	}
+	return strings.TrimSpace(input), nil
+}
+
+func (p *Prompter) readPassword() (string, error) {
+	if p.fd == -1 {
+		return p.readLine()
+	}
+	password, err := terminal.ReadPassword(p.fd)
+	p.printf("\n")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read password")
+	}
+	return string(password), nil
+}
+
+func (p *Prompter) printf(format string, a ...interface{}) {
+	_, _ = fmt.Fprintf(p.out, format, a...)
+}
+
+func parseYesNo(answer string) (bool, bool) {
+	switch strings.ToLower(answer) {
+	case "y", "yes", "true", "1":
+		return true, true
+	case "n", "no", "false", "0":
+		return false, true
+	}
+	return false, false
+}
+
+func chainValidators(validators ...func(string) error) func(string) error {
+	return func(answer string) error {
+		for _, v := range validators {
+			if v == nil {
+				continue
+			}
+			if err := v(answer); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func ValidateEmail(answer string) error {
+	addr, err := mail.ParseAddress(answer)
+	if err != nil || addr.Address != answer || !strings.Contains(answer[strings.LastIndex(answer, "@"):], ".") {
+		return errors.Errorf("'%s' is not a valid email address", answer)
+	}
+	return nil
+}
@@ -1,22 +1,16 @@
 package wwgo
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	"io"
-	"net/http"
 	"os"
-	"strconv"
-	"time"
 )
 
 type SlackWebhookClient struct {
 	webhookUrl     string
 	defaultChannel string
 	log            zerolog.Logger
+	queue          *WebhookQueue
 }
 
 func NewSlackClient(log zerolog.Logger, webhookUrl string, defaultChannel string) *SlackWebhookClient {
@@ -35,10 +29,23 @@ func NewSlackWebhookClientFromEnv(log zerolog.Logger) *SlackWebhookClient {
 	return &SlackWebhookClient{webhookUrl: webhookUrl, defaultChannel: os.Getenv("SLACK_WEBHOOK_CHANNEL"), log: log}
 }
 
+// WithQueue makes the client write messages to the queue instead of sending
+// them directly, nil disables the queue.
+func (s *SlackWebhookClient) WithQueue(queue *WebhookQueue) *SlackWebhookClient {
+	s.queue = queue
+	if queue != nil {
+		queue.RegisterSender(SlackWebhookSender)
+	}
+	return s
+}
+
 func (s *SlackWebhookClient) TrySend(ctx context.Context, message SlackMessagePayload) error {
 	if message.Channel == nil && s.defaultChannel != "" {
 		message.Channel = ToPtr(s.defaultChannel)
 	}
+	if s.queue != nil {
+		return s.queue.Enqueue(SlackWebhookSender, s.webhookUrl, message)
+	}
 	if err := SendSlackWebhook(ctx, s.webhookUrl, message); err != nil {
 		return err
 	}
@@ -56,60 +63,14 @@ type SlackMessagePayload struct {
 	Username  *string `json:"username"`
 	Text      string  `json:"text"`
 	IconEmoji *string `json:"icon_emoji"`
+	// Optional, see SlackMessageBuilder.
+	Blocks      []SlackBlock      `json:"blocks,omitempty"`
+	Attachments []SlackAttachment `json:"attachments,omitempty"`
 }
 
-func SendSlackWebhook(ctx context.Context, webhookUrl string, message SlackMessagePayload) error {
-	firstAttemptAt := time.Now()
-	maxAttemptDuration := 2 * time.Minute
-	for {
-		resp, err := doSendSlack(ctx, webhookUrl, message)
-		if err != nil {
-			return err
-		}
+// SlackWebhookSender is used by SendSlackWebhook.
+var SlackWebhookSender = WebhookSender{Name: "slack"}
 
-		// Handle error response.
-		if resp.StatusCode == 429 {
-			retryAfterStr := resp.Header.Get("Retry-After")
-			retryAfter, _ := strconv.Atoi(retryAfterStr)
-			if retryAfter == 0 {
-				retryAfter = 30
-			}
-
-			// If the next retry time will not exceed the max try time, try again.
-			retryDelay := time.Duration(retryAfter) * time.Second
-			if time.Since(firstAttemptAt)+retryDelay <= maxAttemptDuration {
-				time.Sleep(retryDelay)
-				continue
-			}
-		}
-
-		if resp.StatusCode != 200 {
-			body, _ := io.ReadAll(resp.Body)
-			return errors.Errorf("slack returned %d: %s", resp.StatusCode, body)
-		}
-
-		return nil
-	}
-}
-
-func doSendSlack(ctx context.Context, webhookUrl string, message SlackMessagePayload) (*http.Response, error) {
-	encoded, err := json.Marshal(&message)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to encode slack message")
-	}
-	body := bytes.NewBuffer(encoded)
-
-	client := http.Client{Timeout: time.Second * 30}
-	req, err := http.NewRequestWithContext(ctx, "POST", webhookUrl, body)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to create slack request")
-	}
-	req.Header.Set("Content-Type", "application/json; charset: utf-8")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to send slack")
-	}
-	defer func() { _ = resp.Body.Close() }()
-	return resp, err
+func SendSlackWebhook(ctx context.Context, webhookUrl string, message SlackMessagePayload) error {
+	return SlackWebhookSender.Send(ctx, webhookUrl, message)
 }
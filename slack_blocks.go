@@ -0,0 +1,222 @@
+package wwgo
+
+import (
+	"encoding/json"
+)
+
+// Slack Block Kit, see https://api.slack.com/block-kit.
+
+const (
+	SlackColorGood    = "good"
+	SlackColorWarning = "warning"
+	SlackColorDanger  = "danger"
+)
+
+// SlackBlock is one of the Slack*Block types.
+type SlackBlock interface {
+	SlackBlockType() string
+}
+
+type SlackText struct {
+	// "mrkdwn" or "plain_text".
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func SlackMrkdwn(text string) SlackText {
+	return SlackText{Type: "mrkdwn", Text: text}
+}
+
+func SlackPlainText(text string) SlackText {
+	return SlackText{Type: "plain_text", Text: text}
+}
+
+type SlackButton struct {
+	Text SlackText `json:"text"`
+	Url  string    `json:"url,omitempty"`
+	// Optional, "primary" or "danger".
+	Style    string `json:"style,omitempty"`
+	ActionId string `json:"action_id,omitempty"`
+}
+
+func (b SlackButton) MarshalJSON() ([]byte, error) {
+	type alias SlackButton
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		alias
+	}{"button", alias(b)})
+}
+
+type SlackHeaderBlock struct {
+	Text SlackText `json:"text"`
+}
+
+func (b SlackHeaderBlock) SlackBlockType() string {
+	return "header"
+}
+
+func (b SlackHeaderBlock) MarshalJSON() ([]byte, error) {
+	type alias SlackHeaderBlock
+	return marshalSlackBlock(b, alias(b))
+}
+
+type SlackSectionBlock struct {
+	Text      *SlackText   `json:"text,omitempty"`
+	Fields    []SlackText  `json:"fields,omitempty"`
+	Accessory *SlackButton `json:"accessory,omitempty"`
+}
+
+func (b SlackSectionBlock) SlackBlockType() string {
+	return "section"
+}
+
+func (b SlackSectionBlock) MarshalJSON() ([]byte, error) {
+	type alias SlackSectionBlock
+	return marshalSlackBlock(b, alias(b))
+}
+
+type SlackContextBlock struct {
+	Elements []SlackText `json:"elements"`
+}
+
+func (b SlackContextBlock) SlackBlockType() string {
+	return "context"
+}
+
+func (b SlackContextBlock) MarshalJSON() ([]byte, error) {
+	type alias SlackContextBlock
+	return marshalSlackBlock(b, alias(b))
+}
+
+type SlackDividerBlock struct{}
+
+func (b SlackDividerBlock) SlackBlockType() string {
+	return "divider"
+}
+
+func (b SlackDividerBlock) MarshalJSON() ([]byte, error) {
+	type alias SlackDividerBlock
+	return marshalSlackBlock(b, alias(b))
+}
+
+type SlackActionsBlock struct {
+	Elements []SlackButton `json:"elements"`
+}
+
+func (b SlackActionsBlock) SlackBlockType() string {
+	return "actions"
+}
+
+func (b SlackActionsBlock) MarshalJSON() ([]byte, error) {
+	type alias SlackActionsBlock
+	return marshalSlackBlock(b, alias(b))
+}
+
+// marshalSlackBlock adds the type to the block's JSON, alias must be the
+// block converted to a type without the MarshalJSON method.
+func marshalSlackBlock(b SlackBlock, alias interface{}) ([]byte, error) {
+	fields, err := json.Marshal(alias)
+	if err != nil {
+		return nil, err
+	}
+	res, err := json.Marshal(map[string]string{"type": b.SlackBlockType()})
+	if err != nil {
+		return nil, err
+	}
+	if string(fields) == "{}" {
+		return res, nil
+	}
+	// Merge the objects.
+	return append(append(res[:len(res)-1], ','), fields[1:]...), nil
+}
+
+// SlackAttachment is a legacy attachment, mainly useful for the colour bar.
+type SlackAttachment struct {
+	// i.e. SlackColorDanger or a hex colour "#ff0000".
+	Color     string                 `json:"color,omitempty"`
+	Fallback  string                 `json:"fallback,omitempty"`
+	Title     string                 `json:"title,omitempty"`
+	TitleLink string                 `json:"title_link,omitempty"`
+	Text      string                 `json:"text,omitempty"`
+	Fields    []SlackAttachmentField `json:"fields,omitempty"`
+	Footer    string                 `json:"footer,omitempty"`
+	Ts        int64                  `json:"ts,omitempty"`
+	Blocks    []SlackBlock           `json:"blocks,omitempty"`
+}
+
+type SlackAttachmentField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// SlackMessageBuilder builds a Block Kit message i.e.
+// NewSlackMessageBuilder("fallback").Header("Title").Section("*Hi*").Build().
+type SlackMessageBuilder struct {
+	payload SlackMessagePayload
+}
+
+// NewSlackMessageBuilder starts a message, the text is used for notifications
+// & clients that cannot show blocks.
+func NewSlackMessageBuilder(text string) *SlackMessageBuilder {
+	return &SlackMessageBuilder{payload: SlackMessagePayload{Text: text}}
+}
+
+func (b *SlackMessageBuilder) Block(blocks ...SlackBlock) *SlackMessageBuilder {
+	b.payload.Blocks = append(b.payload.Blocks, blocks...)
+	return b
+}
+
+func (b *SlackMessageBuilder) Header(text string) *SlackMessageBuilder {
+	return b.Block(SlackHeaderBlock{Text: SlackPlainText(TruncateStr(text, 150))})
+}
+
+func (b *SlackMessageBuilder) Section(mrkdwn string) *SlackMessageBuilder {
+	return b.Block(SlackSectionBlock{Text: ToPtr(SlackMrkdwn(TruncateStr(mrkdwn, 3000)))})
+}
+
+// Fields adds a section of "*label*\nvalue" fields from label, value pairs.
+func (b *SlackMessageBuilder) Fields(labelsAndValues ...string) *SlackMessageBuilder {
+	var fields []SlackText
+	for i := 0; i+1 < len(labelsAndValues); i += 2 {
+		fields = append(fields, SlackMrkdwn("*"+labelsAndValues[i]+"*\n"+labelsAndValues[i+1]))
+	}
+	return b.Block(SlackSectionBlock{Fields: fields})
+}
+
+// Code adds a preformatted section, truncated to fit Slack's limit.
+func (b *SlackMessageBuilder) Code(text string) *SlackMessageBuilder {
+	return b.Section("```" + TruncateStr(text, 2990) + "```")
+}
+
+func (b *SlackMessageBuilder) Context(mrkdwn ...string) *SlackMessageBuilder {
+	return b.Block(SlackContextBlock{Elements: MapSlice(mrkdwn, SlackMrkdwn)})
+}
+
+func (b *SlackMessageBuilder) Divider() *SlackMessageBuilder {
+	return b.Block(SlackDividerBlock{})
+}
+
+// Buttons adds link buttons from text, url pairs.
+func (b *SlackMessageBuilder) Buttons(textsAndUrls ...string) *SlackMessageBuilder {
+	var buttons []SlackButton
+	for i := 0; i+1 < len(textsAndUrls); i += 2 {
+		buttons = append(buttons, SlackButton{Text: SlackPlainText(textsAndUrls[i]), Url: textsAndUrls[i+1]})
+	}
+	return b.Block(SlackActionsBlock{Elements: buttons})
+}
+
+// Color moves the blocks into an attachment with the given colour bar.
+func (b *SlackMessageBuilder) Color(color string) *SlackMessageBuilder {
+	b.payload.Attachments = append(b.payload.Attachments, SlackAttachment{
+		Color:    color,
+		Fallback: b.payload.Text,
+		Blocks:   b.payload.Blocks,
+	})
+	b.payload.Blocks = nil
+	return b
+}
+
+func (b *SlackMessageBuilder) Build() SlackMessagePayload {
+	return b.payload
+}
@@ -0,0 +1,285 @@
+package wwgo
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"golang.org/x/net/html"
+	"html/template"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TemplateFuncs are the wwgo helpers available in TemplateRegistry templates.
+var TemplateFuncs = template.FuncMap{
+	"formatDate": FormatDate,
+	// i.e. {{plural .Count "item" "items"}}, see Locale.PluralOf for other
+	// languages.
+	"plural": LocaleEnGb.PluralOf,
+	"truncate": func(maxLen int, str string) string {
+		return TruncateStr(str, maxLen)
+	},
+	"humanize": ScreamingSnakeCaseToHuman,
+	"join":     strings.Join,
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"now":      time.Now,
+	// i.e. {{(locale "cy").FormatDate .Date "Monday 2nd January"}}
+	"locale": LocaleFor,
+	// i.e. {{formatCurrency .Total}} or {{formatCurrency .Total "cy"}}.
+	"formatCurrency": func(d decimal.Decimal, tag ...string) string {
+		if len(tag) == 0 {
+			return LocaleEnGb.FormatCurrency(d)
+		}
+		return LocaleFor(tag[0]).FormatCurrency(d)
+	},
+}
+
+type TemplateRegistryOpt struct {
+	// Optional, templates in this dir are available to all pages, defaults to
+	// "layouts".
+	LayoutsDir string
+	// Optional, as LayoutsDir, defaults to "partials".
+	PartialsDir string
+	// Optional, defaults to ".html".
+	Ext string
+	// Optional, added to (or overriding) TemplateFuncs.
+	Funcs template.FuncMap
+}
+
+// TemplateRegistry parses html templates from a fs.FS (i.e. embed.FS) once.
+//
+// Every other template is a page, named by its path i.e. "emails/welcome.html".
+// A page uses a layout by defining the blocks it needs & calling the layout:
+//
+//	{{define "content"}}<p>Hi {{.Name}}</p>{{end}}
+//	{{template "layouts/email.html" .}}
+//
+// Pages may also define "subject" & "text" templates, see RenderEmail.
+type TemplateRegistry struct {
+	pages map[string]*template.Template
+}
+
+func NewTemplateRegistry(fsys fs.FS, opt TemplateRegistryOpt) (*TemplateRegistry, error) {
+	if opt.LayoutsDir == "" {
+		opt.LayoutsDir = "layouts"
+	}
+	if opt.PartialsDir == "" {
+		opt.PartialsDir = "partials"
+	}
+	if opt.Ext == "" {
+		opt.Ext = ".html"
+	}
+	funcs := template.FuncMap{}
+	for k, v := range TemplateFuncs {
+		funcs[k] = v
+	}
+	for k, v := range opt.Funcs {
+		funcs[k] = v
+	}
+
+	// Find the templates.
+	var shared, pages []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != opt.Ext {
+			return nil
+		}
+		if strings.HasPrefix(p, opt.LayoutsDir+"/") || strings.HasPrefix(p, opt.PartialsDir+"/") {
+			shared = append(shared, p)
+		} else {
+			pages = append(pages, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find templates")
+	}
+
+	// Parse the layouts & partials.
+	base := template.New("").Funcs(funcs)
+	for _, p := range shared {
+		if err := parseTemplateFile(base, fsys, p); err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse the pages, each needs its own copy of the layouts so they can
+	// define the same blocks.
+	r := &TemplateRegistry{pages: make(map[string]*template.Template, len(pages))}
+	for _, p := range pages {
+		tpl, err := base.Clone()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to clone layouts for '%s'", p)
+		}
+		if err := parseTemplateFile(tpl, fsys, p); err != nil {
+			return nil, err
+		}
+		r.pages[p] = tpl
+	}
+	return r, nil
+}
+
+func parseTemplateFile(tpl *template.Template, fsys fs.FS, name string) error {
+	contents, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read template '%s'", name)
+	}
+	if _, err := tpl.New(name).Parse(string(contents)); err != nil {
+		return errors.Wrapf(err, "failed to parse template '%s'", name)
+	}
+	return nil
+}
+
+// Names returns the page names.
+func (r *TemplateRegistry) Names() []string {
+	names := make([]string, 0, len(r.pages))
+	for name := range r.pages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Execute renders the page to w.
+func (r *TemplateRegistry) Execute(w io.Writer, name string, data interface{}) error {
+	return r.execute(w, name, name, data)
+}
+
+// Render renders the page.
+func (r *TemplateRegistry) Render(name string, data interface{}) (string, error) {
+	w := &bytes.Buffer{}
+	if err := r.Execute(w, name, data); err != nil {
+		return "", err
+	}
+	return w.String(), nil
+}
+
+type RenderedEmail struct {
+	Subject string
+	Html    string
+	Text    string
+}
+
+// RenderEmail renders the page with the subject from its "subject" template
+// (if defined) & a plain text alternative from its "text" template, or
+// generated from the html with HtmlToText.
+func (r *TemplateRegistry) RenderEmail(name string, data interface{}) (RenderedEmail, error) {
+	res := RenderedEmail{}
+	var err error
+	if res.Html, err = r.Render(name, data); err != nil {
+		return res, err
+	}
+	res.Html = strings.TrimSpace(res.Html)
+	tpl := r.pages[name]
+	if tpl.Lookup("subject") != nil {
+		w := &bytes.Buffer{}
+		if err := r.execute(w, name, "subject", data); err != nil {
+			return res, err
+		}
+		// The subject is not html, so undo any escaping.
+		res.Subject = strings.TrimSpace(html.UnescapeString(w.String()))
+	}
+	if tpl.Lookup("text") != nil {
+		w := &bytes.Buffer{}
+		if err := r.execute(w, name, "text", data); err != nil {
+			return res, err
+		}
+		res.Text = strings.TrimSpace(html.UnescapeString(w.String()))
+	} else {
+		res.Text = HtmlToText(res.Html)
+	}
+	return res, nil
+}
+
+func (r *TemplateRegistry) execute(w io.Writer, page string, name string, data interface{}) error {
+	tpl, ok := r.pages[page]
+	if !ok {
+		return errors.Errorf("template '%s' does not exist", page)
+	}
+	if err := tpl.ExecuteTemplate(w, name, data); err != nil {
+		return errors.Wrapf(err, "failed to execute template '%s'", page)
+	}
+	return nil
+}
+
+var htmlToTextBlankLines = regexp.MustCompile(`\n{3,}`)
+var htmlToTextSpaces = regexp.MustCompile(`[ \t\r\n]+`)
+
+// HtmlToText converts html to readable plain text, links are followed by
+// their url i.e. "Reset password (https://...)".
+func HtmlToText(src string) string {
+	sb := strings.Builder{}
+	var hrefs []string
+	skip := 0
+	tokenizer := html.NewTokenizer(strings.NewReader(src))
+	for {
+		tt := tokenizer.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+		switch tt {
+		case html.TextToken:
+			if skip == 0 {
+				sb.WriteString(htmlToTextSpaces.ReplaceAllString(token.Data, " "))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch token.Data {
+			case "head", "style", "script", "title":
+				if tt == html.StartTagToken {
+					skip++
+				}
+			case "br":
+				sb.WriteString("\n")
+			case "p", "div", "table", "tr", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol":
+				sb.WriteString("\n\n")
+			case "li":
+				sb.WriteString("\n- ")
+			case "td", "th":
+				sb.WriteString(" ")
+			case "a":
+				href := ""
+				for _, attr := range token.Attr {
+					if attr.Key == "href" {
+						href = attr.Val
+					}
+				}
+				hrefs = append(hrefs, href)
+			}
+		case html.EndTagToken:
+			switch token.Data {
+			case "head", "style", "script", "title":
+				if skip > 0 {
+					skip--
+				}
+			case "p", "div", "table", "tr", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol":
+				sb.WriteString("\n\n")
+			case "a":
+				if len(hrefs) == 0 {
+					continue
+				}
+				href := hrefs[len(hrefs)-1]
+				hrefs = hrefs[:len(hrefs)-1]
+				if href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(href, "mailto:") {
+					sb.WriteString(" (" + href + ")")
+				}
+			}
+		}
+	}
+
+	// Tidy up the whitespace.
+	lines := strings.Split(sb.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text := strings.Join(lines, "\n")
+	return strings.TrimSpace(htmlToTextBlankLines.ReplaceAllString(text, "\n\n"))
+}
@@ -39,20 +39,9 @@ func GenerateRandomString(length int, charset []rune) string {
 }
 
 // FormatDate supports ordinal days i.e. 'Monday 2nd January 2006 15:04:05'.
+// See Locale.FormatDate for other languages.
 func FormatDate(t time.Time, format string) string {
-	if strings.Contains(format, "2nd") {
-		suffix := "th"
-		switch t.Day() {
-		case 1, 21, 31:
-			suffix = "st"
-		case 2, 22:
-			suffix = "nd"
-		case 3, 23:
-			suffix = "rd"
-		}
-		format = strings.ReplaceAll(format, "2nd", "2"+suffix)
-	}
-	return t.Format(format)
+	return LocaleEnGb.FormatDate(t, format)
 }
 
 // ArrayDiffInt32 returns a slice of all values from a that are not in b.
@@ -133,6 +122,8 @@ func ArrayIncludesUUID(haystack []uuid.UUID, needle uuid.UUID) bool {
 	return SliceIncludes(haystack, needle)
 }
 
+// IntArray2StrArray converts the ints to strings.
+// Deprecated: use MapSlice(in, strconv.Itoa) instead.
 func IntArray2StrArray(in []int) []string {
 	out := make([]string, len(in))
 	for i, v := range in {
@@ -250,11 +241,13 @@ func DecimalFromRef(v *decimal.Decimal) decimal.Decimal {
 }
 
 // SqlNullStr will return a sql 'null' value if the string is empty.
+// Deprecated: use NullIfZero instead.
 func SqlNullStr(v string) sql.NullString {
 	return sql.NullString{String: v, Valid: v != ""}
 }
 
 // SqlNullStrRef will return a sql 'null' value if the pointer is nil.
+// Deprecated: use NullFromPtr instead.
 func SqlNullStrRef(v *string) sql.NullString {
 	if v == nil {
 		return sql.NullString{}
@@ -263,11 +256,13 @@ func SqlNullStrRef(v *string) sql.NullString {
 }
 
 // SqlNullInt32 will return a sql 'null' value if the value is 0.
+// Deprecated: use NullIfZero instead.
 func SqlNullInt32(v int32) sql.NullInt32 {
 	return sql.NullInt32{Int32: v, Valid: v != 0}
 }
 
 // SqlNullIntRef will return a sql 'null' value if the pointer is nil.
+// Deprecated: use NullFromPtr instead.
 func SqlNullIntRef(v *int) sql.NullInt32 {
 	if v == nil {
 		return sql.NullInt32{}
@@ -276,11 +271,13 @@ func SqlNullIntRef(v *int) sql.NullInt32 {
 }
 
 // SqlNullTime will return a sql 'null' value if the time is 0.
+// Deprecated: use NullIfZero instead.
 func SqlNullTime(v time.Time) sql.NullTime {
 	return sql.NullTime{Time: v, Valid: !v.IsZero()}
 }
 
 // SqlNullTimeRef will return a sql 'null' value if the pointer is nil.
+// Deprecated: use NullFromPtr instead.
 func SqlNullTimeRef(v *time.Time) sql.NullTime {
 	if v == nil {
 		return sql.NullTime{}
@@ -289,6 +286,7 @@ func SqlNullTimeRef(v *time.Time) sql.NullTime {
 }
 
 // SqlNullBoolRef will return a sql 'null' value if the pointer is nil.
+// Deprecated: use NullFromPtr instead.
 func SqlNullBoolRef(v *bool) sql.NullBool {
 	if v == nil {
 		return sql.NullBool{}
@@ -297,11 +295,13 @@ func SqlNullBoolRef(v *bool) sql.NullBool {
 }
 
 // SqlNullUuid will return a sql 'null' value if the uuid is empty.
+// Deprecated: use NullIfZero instead.
 func SqlNullUuid(v uuid.UUID) uuid.NullUUID {
 	return uuid.NullUUID{UUID: v, Valid: v != uuid.Nil}
 }
 
 // SqlNullUuidRef will return a sql 'null' value if the pointer is nil.
+// Deprecated: use NullFromPtr instead.
 func SqlNullUuidRef(v *uuid.UUID) uuid.NullUUID {
 	if v == nil {
 		return uuid.NullUUID{}
@@ -310,6 +310,7 @@ func SqlNullUuidRef(v *uuid.UUID) uuid.NullUUID {
 }
 
 // UuidRefFromSql will return nil for a 'null' SQL value.
+// Deprecated: use Null.Ptr instead.
 func UuidRefFromSql(v uuid.NullUUID) *uuid.UUID {
 	if !v.Valid {
 		return nil
@@ -318,11 +319,13 @@ func UuidRefFromSql(v uuid.NullUUID) *uuid.UUID {
 }
 
 // SqlNullDecimal will return a sql 'null' value if the decimal is zero.
+// Deprecated: use NullIfZero instead.
 func SqlNullDecimal(v decimal.Decimal) decimal.NullDecimal {
 	return decimal.NullDecimal{Decimal: v, Valid: !v.IsZero()}
 }
 
 // SqlNullDecimalRef will return a sql 'null' value if the pointer is nil.
+// Deprecated: use NullFromPtr instead.
 func SqlNullDecimalRef(v *decimal.Decimal) decimal.NullDecimal {
 	if v == nil {
 		return decimal.NullDecimal{}
@@ -331,6 +334,7 @@ func SqlNullDecimalRef(v *decimal.Decimal) decimal.NullDecimal {
 }
 
 // StrRefFromSql will return nil for a 'null' SQL value.
+// Deprecated: use Null.Ptr instead.
 func StrRefFromSql(v sql.NullString) *string {
 	if !v.Valid {
 		return nil
@@ -339,6 +343,7 @@ func StrRefFromSql(v sql.NullString) *string {
 }
 
 // IntRefFromSql will return nil for a 'null' SQL value.
+// Deprecated: use Null.Ptr instead.
 func IntRefFromSql(v sql.NullInt32) *int {
 	if !v.Valid {
 		return nil
@@ -347,6 +352,7 @@ func IntRefFromSql(v sql.NullInt32) *int {
 }
 
 // DecimalRefFromSql will return nil for a 'null' SQL value.
+// Deprecated: use Null.Ptr instead.
 func DecimalRefFromSql(v decimal.NullDecimal) *decimal.Decimal {
 	if !v.Valid {
 		return nil
@@ -355,6 +361,7 @@ func DecimalRefFromSql(v decimal.NullDecimal) *decimal.Decimal {
 }
 
 // TimeRefFromSql will return nil for a 'null' SQL value.
+// Deprecated: use Null.Ptr instead.
 func TimeRefFromSql(v sql.NullTime) *time.Time {
 	if !v.Valid {
 		return nil
@@ -564,15 +571,9 @@ func FilterSlice[T any](s []T, f func(T) bool) []T {
 // DiffSlice returns a slice of all values from a that are not in b.
 func DiffSlice[T comparable](a []T, b []T) []T {
 	diff := make([]T, 0)
+	bSet := SliceToSet(b)
 	for _, aItem := range a {
-		found := false
-		for _, bItem := range b {
-			if bItem == aItem {
-				found = true
-				break
-			}
-		}
-		if !found {
+		if _, found := bSet[aItem]; !found {
 			diff = append(diff, aItem)
 		}
 	}
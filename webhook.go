@@ -0,0 +1,160 @@
+package wwgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// WebhookHttpClient is shared by all webhooks so connections are reused.
+var WebhookHttpClient = &http.Client{
+	Timeout: 30 * time.Second,
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 20 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	},
+}
+
+// WebhookError is returned when a webhook responds with a non 2xx status.
+type WebhookError struct {
+	// i.e. "slack".
+	Name       string
+	StatusCode int
+	Body       string
+	// Zero if the response did not have a Retry-After header.
+	RetryAfter time.Duration
+}
+
+func (e *WebhookError) Error() string {
+	return fmt.Sprintf("%s returned %d: %s", e.Name, e.StatusCode, e.Body)
+}
+
+// Temporary is true if the request may succeed if retried.
+func (e *WebhookError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// WebhookSender POSTs JSON to webhooks, retrying 429, 5xx & network errors.
+type WebhookSender struct {
+	// Name used in errors & logs i.e. "slack".
+	Name string
+	// Optional, defaults to WebhookHttpClient.
+	Client *http.Client
+	// Optional, defaults to 1 second.
+	InitialInterval time.Duration
+	// Optional, defaults to 2 minutes. A negative value disables retries.
+	MaxElapsedTime time.Duration
+}
+
+// Send encodes the payload as JSON & sends it.
+func (s WebhookSender) Send(ctx context.Context, url string, payload interface{}) error {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode %s message", s.Name)
+	}
+	return s.SendRaw(ctx, url, encoded)
+}
+
+// SendRaw sends the already encoded JSON body.
+func (s WebhookSender) SendRaw(ctx context.Context, url string, body []byte) error {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = time.Second
+	if s.InitialInterval != 0 {
+		b.InitialInterval = s.InitialInterval
+	}
+	b.MaxElapsedTime = 2 * time.Minute
+	if s.MaxElapsedTime != 0 {
+		b.MaxElapsedTime = s.MaxElapsedTime
+	}
+	b.Reset()
+
+	for {
+		err := s.doSend(ctx, url, body)
+		if err == nil || s.MaxElapsedTime < 0 || !isTemporaryWebhookError(err) {
+			return err
+		}
+
+		// Wait for the next attempt, respecting the Retry-After header.
+		delay := b.NextBackOff()
+		if delay == backoff.Stop {
+			return err
+		}
+		var webhookErr *WebhookError
+		if errors.As(err, &webhookErr) && webhookErr.RetryAfter != 0 {
+			if b.GetElapsedTime()+webhookErr.RetryAfter > b.MaxElapsedTime {
+				return err
+			}
+			delay = webhookErr.RetryAfter
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Wrapf(ctx.Err(), "gave up retrying %s after: %s", s.Name, err)
+		case <-timer.C:
+		}
+	}
+}
+
+func (s WebhookSender) doSend(ctx context.Context, url string, body []byte) error {
+	client := s.Client
+	if client == nil {
+		client = WebhookHttpClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return backoff.Permanent(errors.Wrapf(err, "failed to create %s request", s.Name))
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to send to %s", s.Name)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Always read the body so the connection can be reused.
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+	return &WebhookError{
+		Name:       s.Name,
+		StatusCode: resp.StatusCode,
+		Body:       string(respBody),
+		RetryAfter: time.Duration(retryAfter) * time.Second,
+	}
+}
+
+func isTemporaryWebhookError(err error) bool {
+	var permanent *backoff.PermanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	var webhookErr *WebhookError
+	if errors.As(err, &webhookErr) {
+		return webhookErr.Temporary()
+	}
+	// Network error.
+	return true
+}
@@ -0,0 +1,220 @@
+package wwgo
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebhookQueueOpt configures a WebhookQueue.
+type WebhookQueueOpt struct {
+	// Optional, how often failed webhooks are retried, defaults to 1 minute.
+	RetryInterval time.Duration
+	// Optional, webhooks older than this are dropped, defaults to 24 hours.
+	MaxAge time.Duration
+}
+
+// WebhookQueue persists webhooks to a directory so they survive restarts,
+// they are sent by Start.
+type WebhookQueue struct {
+	WebhookQueueOpt
+	log     zerolog.Logger
+	dir     string
+	wake    chan struct{}
+	senders map[string]WebhookSender
+	mut     sync.Mutex
+}
+
+type webhookQueueItem struct {
+	Sender    string          `json:"sender"`
+	Url       string          `json:"url"`
+	Body      json.RawMessage `json:"body"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Attempts  int             `json:"attempts"`
+}
+
+func NewWebhookQueue(log zerolog.Logger, dir string, opt WebhookQueueOpt) (*WebhookQueue, error) {
+	if opt.RetryInterval == 0 {
+		opt.RetryInterval = time.Minute
+	}
+	if opt.MaxAge == 0 {
+		opt.MaxAge = 24 * time.Hour
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrapf(err, "failed to create webhook queue dir '%s'", dir)
+	}
+	return &WebhookQueue{
+		WebhookQueueOpt: opt,
+		log:             log,
+		dir:             dir,
+		wake:            make(chan struct{}, 1),
+		senders:         map[string]WebhookSender{},
+	}, nil
+}
+
+// RegisterSender sets the sender used for queued webhooks with its name, so
+// that webhooks queued before a restart use its settings. Enqueue registers
+// the sender automatically.
+func (q *WebhookQueue) RegisterSender(sender WebhookSender) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+	q.senders[sender.Name] = sender
+}
+
+// sender returns the registered sender, without retries as they are handled by
+// the queue.
+func (q *WebhookQueue) sender(name string) WebhookSender {
+	q.mut.Lock()
+	sender, ok := q.senders[name]
+	q.mut.Unlock()
+	if !ok {
+		sender = WebhookSender{Name: name}
+	}
+	sender.MaxElapsedTime = -1
+	return sender
+}
+
+// Enqueue writes the webhook to disk, it will be sent with the sender's
+// settings by Start (retries are handled by the queue, see RetryInterval).
+func (q *WebhookQueue) Enqueue(sender WebhookSender, url string, payload interface{}) error {
+	q.RegisterSender(sender)
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode %s message", sender.Name)
+	}
+	item := webhookQueueItem{
+		Sender:    sender.Name,
+		Url:       url,
+		Body:      encoded,
+		CreatedAt: time.Now(),
+	}
+	// Prefix with the time so the queue is processed in order.
+	name := item.CreatedAt.UTC().Format("20060102T150405.000000000") + "-" + uuid.NewString() + ".json"
+	if err := q.write(name, item); err != nil {
+		return err
+	}
+
+	// Wake up the worker.
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Len returns the number of queued webhooks.
+func (q *WebhookQueue) Len() (int, error) {
+	names, err := q.list()
+	return len(names), err
+}
+
+// Start sends queued webhooks until the context is cancelled, then returns
+// http.ErrServerClosed.
+func (q *WebhookQueue) Start(ctx context.Context) error {
+	ticker := time.NewTicker(q.RetryInterval)
+	defer ticker.Stop()
+	for {
+		q.process(ctx)
+		select {
+		case <-ctx.Done():
+			return http.ErrServerClosed
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+func (q *WebhookQueue) process(ctx context.Context) {
+	names, err := q.list()
+	if err != nil {
+		q.log.Err(err).Msg("Failed to list webhook queue")
+		return
+	}
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := q.processItem(ctx, name); err != nil {
+			q.log.Err(err).Msgf("Failed to process queued webhook %s", name)
+		}
+	}
+}
+
+func (q *WebhookQueue) processItem(ctx context.Context, name string) error {
+	item := webhookQueueItem{}
+	contents, err := os.ReadFile(filepath.Join(q.dir, name))
+	if err != nil {
+		return errors.Wrapf(err, "failed to read")
+	}
+	if err := json.Unmarshal(contents, &item); err != nil {
+		q.log.Error().Err(err).Msgf("Dropping invalid queued webhook %s", name)
+		return q.remove(name)
+	}
+
+	err = q.sender(item.Sender).SendRaw(ctx, item.Url, item.Body)
+	if err == nil {
+		return q.remove(name)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	item.Attempts++
+	if !isTemporaryWebhookError(err) {
+		q.log.Error().Err(err).Msgf("Dropping queued %s webhook after permanent error", item.Sender)
+		return q.remove(name)
+	}
+	if time.Since(item.CreatedAt) > q.MaxAge {
+		q.log.Error().Err(err).Msgf("Dropping queued %s webhook after %d attempts", item.Sender, item.Attempts)
+		return q.remove(name)
+	}
+	q.log.Warn().Err(err).Msgf("Failed to send queued %s webhook, will retry", item.Sender)
+	return q.write(name, item)
+}
+
+func (q *WebhookQueue) list() ([]string, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read webhook queue dir '%s'", q.dir)
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// write is atomic so a crash never leaves a partial file.
+func (q *WebhookQueue) write(name string, item webhookQueueItem) error {
+	encoded, err := json.Marshal(item)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode queued webhook")
+	}
+	tmp := filepath.Join(q.dir, name+".tmp")
+	if err := os.WriteFile(tmp, encoded, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write queued webhook")
+	}
+	if err := os.Rename(tmp, filepath.Join(q.dir, name)); err != nil {
+		return errors.Wrapf(err, "failed to write queued webhook")
+	}
+	return nil
+}
+
+func (q *WebhookQueue) remove(name string) error {
+	if err := os.Remove(filepath.Join(q.dir, name)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove queued webhook")
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package wwalert
+
+import (
+	"fmt"
+	"strings"
+)
+
+type AlertSeverity string
+
+const (
+	AlertSeverityInfo    AlertSeverity = "info"
+	AlertSeverityWarning AlertSeverity = "warning"
+	AlertSeverityError   AlertSeverity = "error"
+)
+
+type AlertField struct {
+	Name  string
+	Value string
+}
+
+type AlertLink struct {
+	Text string
+	Url  string
+}
+
+// Alert is a structured alert, see SlackAlerter.SendStructuredAlert.
+type Alert struct {
+	// Optional, defaults to AlertSeverityError.
+	Severity AlertSeverity
+	Title    string
+	Message  string
+	// Optional, i.e. fmt.Sprintf("%+v", err).
+	Stack string
+	// Optional.
+	Fields []AlertField
+	// Optional, i.e. links to dashboards.
+	Links []AlertLink
+}
+
+// String formats the alert as plain text, for alerters that do not support
+// structured alerts.
+func (a Alert) String() string {
+	sb := strings.Builder{}
+	sb.WriteString(a.Title)
+	if a.Message != "" {
+		sb.WriteString("\n" + a.Message)
+	}
+	for _, f := range a.Fields {
+		sb.WriteString(fmt.Sprintf("\n%s: %s", f.Name, f.Value))
+	}
+	for _, l := range a.Links {
+		sb.WriteString(fmt.Sprintf("\n%s: %s", l.Text, l.Url))
+	}
+	if a.Stack != "" {
+		sb.WriteString("\n" + a.Stack)
+	}
+	return sb.String()
+}
+
+func (a Alert) isPlain() bool {
+	return a.Severity == "" && a.Message == "" && a.Stack == "" && len(a.Fields) == 0 && len(a.Links) == 0
+}
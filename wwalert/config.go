@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
+	"github.com/weavingwebs/wwgo"
 	"golang.org/x/sync/errgroup"
 	"gopkg.in/yaml.v2"
 	"os"
@@ -39,6 +40,7 @@ type ConfigAlerter struct {
 	log     zerolog.Logger
 	config  *AlertsConfig
 	appName string
+	queue   *wwgo.WebhookQueue
 }
 
 func NewConfigAlerter(log zerolog.Logger, config *AlertsConfig, appName string) *ConfigAlerter {
@@ -61,7 +63,24 @@ func NewConfigAlerterFromEnv(log zerolog.Logger, appName string) (*ConfigAlerter
 	return NewConfigAlerter(log, config, appName), nil
 }
 
+// WithQueue queues alerts on disk so they survive restarts, the queue must be
+// started separately.
+func (a *ConfigAlerter) WithQueue(queue *wwgo.WebhookQueue) *ConfigAlerter {
+	a.queue = queue
+	if queue != nil {
+		queue.RegisterSender(wwgo.SlackWebhookSender)
+		queue.RegisterSender(MsTeamsWebhookSender)
+	}
+	return a
+}
+
 func (a *ConfigAlerter) SendAlert(ctx context.Context, category string, msg string) error {
+	return a.SendStructuredAlert(ctx, category, Alert{Title: msg})
+}
+
+// SendStructuredAlert sends a structured alert to slack, other alerters
+// receive Alert.String().
+func (a *ConfigAlerter) SendStructuredAlert(ctx context.Context, category string, alert Alert) error {
 	// Figure out what config to use.
 	alertConfig := a.config.Default
 	if category != "" {
@@ -82,11 +101,13 @@ func (a *ConfigAlerter) SendAlert(ctx context.Context, category string, msg stri
 				Log:             a.log,
 				Configs:         alertConfig.Slack,
 				DefaultUsername: a.appName,
+				Queue:           a.queue,
 			}
-			if err := alerter.SendAlert(ctx, msg); err != nil {
-				return err
+			// Plain messages are sent as they were.
+			if alert.isPlain() {
+				return alerter.SendAlert(ctx, alert.Title)
 			}
-			return nil
+			return alerter.SendStructuredAlert(ctx, alert)
 		})
 	}
 
@@ -96,8 +117,9 @@ func (a *ConfigAlerter) SendAlert(ctx context.Context, category string, msg stri
 				Log:           a.log,
 				Configs:       alertConfig.MsTeams,
 				DefaultPrefix: a.appName,
+				Queue:         a.queue,
 			}
-			if err := alerter.SendAlert(ctx, msg); err != nil {
+			if err := alerter.SendAlert(ctx, alert.String()); err != nil {
 				return err
 			}
 			return nil
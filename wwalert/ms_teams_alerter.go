@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
+	"github.com/weavingwebs/wwgo"
 	"strings"
 )
 
@@ -21,6 +22,8 @@ type MsTeamsAlerter struct {
 	Log           zerolog.Logger
 	Configs       []MsTeamsAlertConfig
 	DefaultPrefix string
+	// Optional, see wwgo.WebhookQueue.
+	Queue *wwgo.WebhookQueue
 }
 
 func (sa MsTeamsAlerter) SendAlert(ctx context.Context, msg string) error {
@@ -50,9 +53,15 @@ func (sa MsTeamsAlerter) SendAlert(ctx context.Context, msg string) error {
 		}
 
 		// Send.
-		err := SendMsTeams(ctx, webhook, MsTeamsMessagePayload{
+		message := MsTeamsMessagePayload{
 			Text: prefix + msg,
-		})
+		}
+		var err error
+		if sa.Queue != nil {
+			err = sa.Queue.Enqueue(MsTeamsWebhookSender, webhook, message)
+		} else {
+			err = SendMsTeams(ctx, webhook, message)
+		}
 		if err != nil {
 			sa.Log.Err(err).Msgf("Failed to send MS Teams alert")
 			continue
@@ -5,6 +5,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/weavingwebs/wwgo"
+	"os"
 	"strings"
 )
 
@@ -25,9 +26,67 @@ type SlackAlerter struct {
 	Log             zerolog.Logger
 	Configs         []SlackAlertConfig
 	DefaultUsername string
+	// Optional, see wwgo.WebhookQueue.
+	Queue *wwgo.WebhookQueue
 }
 
 func (sa SlackAlerter) SendAlert(ctx context.Context, msg string) error {
+	return sa.send(ctx, func(username string) wwgo.SlackMessagePayload {
+		return wwgo.SlackMessagePayload{Text: msg}
+	})
+}
+
+// SendStructuredAlert sends the alert with a severity colour, the app name,
+// host & stack trace.
+func (sa SlackAlerter) SendStructuredAlert(ctx context.Context, alert Alert) error {
+	color := wwgo.SlackColorDanger
+	switch alert.Severity {
+	case AlertSeverityInfo:
+		color = wwgo.SlackColorGood
+	case AlertSeverityWarning:
+		color = wwgo.SlackColorWarning
+	}
+	hostname, _ := os.Hostname()
+
+	return sa.send(ctx, func(username string) wwgo.SlackMessagePayload {
+		b := wwgo.NewSlackMessageBuilder(alert.Title)
+		b.Header(alert.Title)
+		if alert.Message != "" {
+			b.Section(alert.Message)
+		}
+		// Slack allows up to 10 fields per section.
+		for _, fields := range wwgo.ChunkSlice(alert.Fields, 10) {
+			var labelsAndValues []string
+			for _, f := range fields {
+				labelsAndValues = append(labelsAndValues, f.Name, f.Value)
+			}
+			b.Fields(labelsAndValues...)
+		}
+		if alert.Stack != "" {
+			b.Code(alert.Stack)
+		}
+		if len(alert.Links) != 0 {
+			var textsAndUrls []string
+			for _, l := range alert.Links {
+				textsAndUrls = append(textsAndUrls, l.Text, l.Url)
+			}
+			b.Buttons(textsAndUrls...)
+		}
+		var contextLines []string
+		if username != "" {
+			contextLines = append(contextLines, "*App:* "+username)
+		}
+		if hostname != "" {
+			contextLines = append(contextLines, "*Host:* "+hostname)
+		}
+		if len(contextLines) != 0 {
+			b.Context(contextLines...)
+		}
+		return b.Color(color).Build()
+	})
+}
+
+func (sa SlackAlerter) send(ctx context.Context, payload func(username string) wwgo.SlackMessagePayload) error {
 	// Send alerts.
 	sent := 0
 	for i, c := range sa.Configs {
@@ -51,14 +110,12 @@ func (sa SlackAlerter) SendAlert(ctx context.Context, msg string) error {
 		}
 
 		// Send.
-		slackClient := wwgo.NewSlackClient(sa.Log, webhook, c.Channel)
-		err := slackClient.TrySend(ctx, wwgo.SlackMessagePayload{
-			Channel:   wwgo.StrNilIfEmpty(strings.TrimSpace(c.Channel)),
-			Username:  wwgo.StrNilIfEmpty(username),
-			Text:      msg,
-			IconEmoji: wwgo.StrNilIfEmpty(strings.TrimSpace(c.IconEmoji)),
-		})
-		if err != nil {
+		message := payload(username)
+		message.Channel = wwgo.StrNilIfEmpty(strings.TrimSpace(c.Channel))
+		message.Username = wwgo.StrNilIfEmpty(username)
+		message.IconEmoji = wwgo.StrNilIfEmpty(strings.TrimSpace(c.IconEmoji))
+		slackClient := wwgo.NewSlackClient(sa.Log, webhook, c.Channel).WithQueue(sa.Queue)
+		if err := slackClient.TrySend(ctx, message); err != nil {
 			sa.Log.Err(err).Msgf("Failed to send slack alert")
 			continue
 		}
@@ -142,6 +142,17 @@ func JwtFromContext(ctx context.Context) string {
 	return ctx.Value(JwtCtxKey).(string)
 }
 
+// JwtSubjectFromContext returns the subject of the token verified by
+// JwtAuth.JwtMiddleware, or "" if there is none.
+func JwtSubjectFromContext(ctx context.Context) string {
+	token, ok := ctx.Value(JwtCtxKey).(*jwt.Token)
+	if !ok || token.Claims == nil {
+		return ""
+	}
+	sub, _ := token.Claims.GetSubject()
+	return sub
+}
+
 func JwtMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := ContextWithJwt(r.Context(), TokenFromHeader(r))
@@ -28,6 +28,8 @@ type Email struct {
 	Bcc       []string
 	Subject   string
 	HtmlBody  string
+	// Optional, plain text alternative i.e. from wwgo.RenderedEmail.
+	TextBody string
 }
 
 func NewSesMailer(
@@ -79,6 +81,14 @@ func (s *SesMailer) Send(ctx context.Context, email Email) error {
 		source = email.FromLabel + " <" + s.fromAddress + ">"
 	}
 
+	var textBody *types.Content
+	if email.TextBody != "" {
+		textBody = &types.Content{
+			Charset: aws.String("UTF-8"),
+			Data:    aws.String(email.TextBody),
+		}
+	}
+
 	_, err := s.sesClient.SendEmail(ctx, &ses.SendEmailInput{
 		Destination: &types.Destination{
 			BccAddresses: bcc,
@@ -91,6 +101,7 @@ func (s *SesMailer) Send(ctx context.Context, email Email) error {
 					Charset: aws.String("UTF-8"),
 					Data:    aws.String(email.HtmlBody),
 				},
+				Text: textBody,
 			},
 			Subject: &types.Content{
 				Charset: aws.String("UTF-8"),
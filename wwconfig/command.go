@@ -0,0 +1,72 @@
+package wwconfig
+
+import (
+	"fmt"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+	"os"
+	"strings"
+)
+
+// Command prints the config loaded by fields (i.e. a closure calling Fields),
+// secrets are redacted. The config is printed even if it is invalid, followed
+// by the errors.
+func Command(fields func(ctx *cli.Context) ([]Field, error)) *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Config commands",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "print",
+				Usage: "Print the config & where each value came from, secrets are redacted",
+				Action: func(ctx *cli.Context) error {
+					res, err := fields(ctx)
+
+					table := tablewriter.NewWriter(os.Stdout)
+					table.SetHeader([]string{
+						"Field",
+						"Env",
+						"Value",
+						"Source",
+					})
+					for _, f := range res {
+						table.Append([]string{
+							f.Path,
+							f.Env,
+							RedactValue(f),
+							f.Source,
+						})
+					}
+					table.Render()
+
+					if err != nil {
+						fmt.Println()
+						return err
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// RedactValue hides secrets & values that look like secrets.
+func RedactValue(f Field) string {
+	if f.Value == "" {
+		return ""
+	}
+	if f.Secret || looksSecret(f.Env) {
+		return "********"
+	}
+	return f.Value
+}
+
+func looksSecret(env string) bool {
+	env = strings.ToUpper(env)
+	for _, s := range []string{"PASSWORD", "SECRET", "TOKEN", "PRIVATE_KEY", "API_KEY"} {
+		if strings.Contains(env, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,254 @@
+package wwconfig
+
+import (
+	"context"
+	"encoding"
+	"github.com/joho/godotenv"
+	"github.com/pkg/errors"
+	"github.com/weavingwebs/wwgo"
+	"github.com/weavingwebs/wwgo/wwalert"
+	"gopkg.in/yaml.v2"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AwsSecretPrefix marks a value as a wwalert.AwsSecret reference, i.e.
+// "awssecret:my-secret-id#password".
+const AwsSecretPrefix = "awssecret:"
+
+// Load fills the exported fields of the struct pointed to by dst. Fields are
+// configured with tags:
+//
+//	type Config struct {
+//		DbHost     string        `env:"DB_HOST" default:"localhost"`
+//		DbPassword string        `env:"DB_PASSWORD" required:"true" secret:"true"`
+//		Timeout    time.Duration `env:"TIMEOUT" default:"30s"`
+//		Ses        SesConfig     `env:"SES"` // Nested, i.e. SES_FROM_ADDRESS.
+//	}
+//
+// Values are taken from (highest priority first): the environment, the
+// LoadOpt.EnvFiles, the LoadOpt.YamlFile (keyed by the env name) and the
+// default. Any value may be an AwsSecretPrefix reference. Every missing or
+// invalid value is returned in a *wwgo.MultiError.
+func Load(ctx context.Context, dst interface{}, opt LoadOpt) error {
+	_, err := load(ctx, dst, opt)
+	return err
+}
+
+type LoadOpt struct {
+	// Optional, .env files, missing files are ignored.
+	EnvFiles []string
+	// Optional, a YAML map of env names to values, a missing file is ignored.
+	YamlFile string
+	// Optional, prepended to every env name, i.e. "MYAPP_".
+	EnvPrefix string
+}
+
+// Field describes a loaded value, see Fields.
+type Field struct {
+	// i.e. "Ses.FromAddress".
+	Path   string
+	Env    string
+	Value  string
+	Secret bool
+	// "env", ".env", "yaml", "default" or "" if not set.
+	Source string
+}
+
+// Fields loads the config & describes where each value came from, for
+// `config print`.
+func Fields(ctx context.Context, dst interface{}, opt LoadOpt) ([]Field, error) {
+	return load(ctx, dst, opt)
+}
+
+type loader struct {
+	ctx    context.Context
+	dotEnv map[string]string
+	yaml   map[string]string
+	fields []Field
+	errs   []error
+}
+
+func load(ctx context.Context, dst interface{}, opt LoadOpt) ([]Field, error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.Errorf("config must be a pointer to a struct, got %T", dst)
+	}
+
+	l := &loader{
+		ctx:    ctx,
+		dotEnv: map[string]string{},
+		yaml:   map[string]string{},
+	}
+
+	// Read the files.
+	for _, file := range opt.EnvFiles {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			continue
+		}
+		values, err := godotenv.Read(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read '%s'", file)
+		}
+		// Earlier files take priority, like godotenv.Load.
+		for k, v := range values {
+			if _, ok := l.dotEnv[k]; !ok {
+				l.dotEnv[k] = v
+			}
+		}
+	}
+	if opt.YamlFile != "" {
+		contents, err := os.ReadFile(opt.YamlFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "failed to read '%s'", opt.YamlFile)
+		}
+		if err == nil {
+			if err := yaml.Unmarshal(contents, &l.yaml); err != nil {
+				return nil, errors.Wrapf(err, "failed to decode '%s'", opt.YamlFile)
+			}
+		}
+	}
+
+	l.loadStruct(v.Elem(), "", opt.EnvPrefix)
+	return l.fields, wwgo.NewMultiError(l.errs)
+}
+
+func (l *loader) loadStruct(v reflect.Value, pathPrefix string, envPrefix string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		env, ok := sf.Tag.Lookup("env")
+		if !sf.IsExported() || !ok || env == "-" {
+			continue
+		}
+		path := pathPrefix + sf.Name
+		env = envPrefix + env
+		fv := v.Field(i)
+
+		// Nested config.
+		_, isText := fv.Addr().Interface().(encoding.TextUnmarshaler)
+		if sf.Type.Kind() == reflect.Struct && !isText {
+			l.loadStruct(fv, path+".", env+"_")
+			continue
+		}
+
+		field := Field{
+			Path:   path,
+			Env:    env,
+			Secret: sf.Tag.Get("secret") == "true",
+		}
+		raw, source := l.lookup(env, sf.Tag)
+		field.Source = source
+		if source == "" {
+			if sf.Tag.Get("required") == "true" {
+				l.errs = append(l.errs, errors.Errorf("%s is not set", env))
+			}
+			l.fields = append(l.fields, field)
+			continue
+		}
+
+		// Resolve secrets.
+		if strings.HasPrefix(raw, AwsSecretPrefix) {
+			field.Secret = true
+			id, prop, _ := strings.Cut(strings.TrimPrefix(raw, AwsSecretPrefix), "#")
+			resolved, err := wwalert.AwsSecret{Id: id, Prop: prop}.Resolve(l.ctx)
+			if err != nil {
+				l.errs = append(l.errs, errors.Wrapf(err, "%s: failed to resolve aws secret", env))
+				l.fields = append(l.fields, field)
+				continue
+			}
+			raw = resolved
+		}
+
+		field.Value = raw
+		if err := setValue(fv, raw); err != nil {
+			l.errs = append(l.errs, errors.Wrapf(err, "%s is invalid", env))
+		}
+		l.fields = append(l.fields, field)
+	}
+}
+
+func (l *loader) lookup(env string, tag reflect.StructTag) (string, string) {
+	if v, ok := os.LookupEnv(env); ok {
+		return v, "env"
+	}
+	if v, ok := l.dotEnv[env]; ok {
+		return v, ".env"
+	}
+	if v, ok := l.yaml[env]; ok {
+		return v, "yaml"
+	}
+	if v, ok := tag.Lookup("default"); ok {
+		return v, "default"
+	}
+	return "", ""
+}
+
+func setValue(v reflect.Value, raw string) error {
+	// Pointers are set to a new value.
+	if v.Kind() == reflect.Pointer {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setValue(ptr.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+	switch v.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		parts := wwgo.SplitTrimAndFilterString(raw, ",")
+		s := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setValue(s.Index(i), part); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	default:
+		return errors.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package wwconfig
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/weavingwebs/wwgo"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testLevel is a struct but also a TextUnmarshaler, so it must not be treated
+// as a nested config.
+type testLevel struct {
+	name string
+}
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug", "info":
+		l.name = string(text)
+		return nil
+	}
+	return errors.Errorf("unknown level '%s'", text)
+}
+
+type testSesConfig struct {
+	FromAddress string `env:"FROM_ADDRESS"`
+	Region      string `env:"REGION" default:"eu-west-2"`
+}
+
+func writeTestFile(t *testing.T, name string, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	type config struct {
+		FromEnv     string `env:"TEST_FROM_ENV" default:"default"`
+		FromDotEnv  string `env:"TEST_FROM_DOT_ENV" default:"default"`
+		FromYaml    string `env:"TEST_FROM_YAML" default:"default"`
+		FromDefault string `env:"TEST_FROM_DEFAULT" default:"default"`
+		Unset       string `env:"TEST_UNSET"`
+	}
+	envFile := writeTestFile(t, ".env", "TEST_FROM_ENV=dotenv\nTEST_FROM_DOT_ENV=dotenv\n")
+	yamlFile := writeTestFile(t, "config.yml", "TEST_FROM_ENV: yaml\nTEST_FROM_DOT_ENV: yaml\nTEST_FROM_YAML: yaml\n")
+	t.Setenv("TEST_FROM_ENV", "env")
+
+	var cfg config
+	fields, err := Fields(context.Background(), &cfg, LoadOpt{
+		EnvFiles: []string{envFile, filepath.Join(t.TempDir(), "missing.env")},
+		YamlFile: yamlFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := config{
+		FromEnv:     "env",
+		FromDotEnv:  "dotenv",
+		FromYaml:    "yaml",
+		FromDefault: "default",
+	}
+	if cfg != expected {
+		t.Fatalf("expected %+v, got %+v", expected, cfg)
+	}
+
+	sources := map[string]string{}
+	for _, f := range fields {
+		sources[f.Path] = f.Source
+	}
+	for path, source := range map[string]string{
+		"FromEnv":     "env",
+		"FromDotEnv":  ".env",
+		"FromYaml":    "yaml",
+		"FromDefault": "default",
+		"Unset":       "",
+	} {
+		if sources[path] != source {
+			t.Errorf("expected %s to come from '%s', got '%s'", path, source, sources[path])
+		}
+	}
+}
+
+func TestLoadNestedEnvPrefix(t *testing.T) {
+	type config struct {
+		Ses   testSesConfig `env:"SES"`
+		Level testLevel     `env:"LEVEL"`
+	}
+	t.Setenv("MYAPP_SES_FROM_ADDRESS", "test@example.com")
+	t.Setenv("MYAPP_LEVEL", "debug")
+	// Without the prefix, so it must be ignored.
+	t.Setenv("SES_REGION", "us-east-1")
+
+	var cfg config
+	if err := Load(context.Background(), &cfg, LoadOpt{EnvPrefix: "MYAPP_"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Ses.FromAddress != "test@example.com" || cfg.Ses.Region != "eu-west-2" {
+		t.Fatalf("unexpected nested config %+v", cfg.Ses)
+	}
+	if cfg.Level.name != "debug" {
+		t.Fatalf("expected the level to be unmarshalled, got %+v", cfg.Level)
+	}
+}
+
+func TestLoadTypes(t *testing.T) {
+	type config struct {
+		Timeout   time.Duration   `env:"TEST_TIMEOUT"`
+		Port      *int            `env:"TEST_PORT"`
+		Missing   *int            `env:"TEST_MISSING"`
+		Hosts     []string        `env:"TEST_HOSTS"`
+		Ports     []int           `env:"TEST_PORTS"`
+		Enabled   bool            `env:"TEST_ENABLED"`
+		Ratio     float64         `env:"TEST_RATIO"`
+		Level     *testLevel      `env:"TEST_LEVEL"`
+		Intervals []time.Duration `env:"TEST_INTERVALS"`
+	}
+	t.Setenv("TEST_TIMEOUT", "1m30s")
+	t.Setenv("TEST_PORT", "8080")
+	t.Setenv("TEST_HOSTS", "a, b,,c")
+	t.Setenv("TEST_PORTS", "80,443")
+	t.Setenv("TEST_ENABLED", "true")
+	t.Setenv("TEST_RATIO", "0.5")
+	t.Setenv("TEST_LEVEL", "info")
+	t.Setenv("TEST_INTERVALS", "1s,2m")
+
+	var cfg config
+	if err := Load(context.Background(), &cfg, LoadOpt{}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Timeout != 90*time.Second {
+		t.Errorf("expected 1m30s, got %s", cfg.Timeout)
+	}
+	if cfg.Port == nil || *cfg.Port != 8080 {
+		t.Errorf("expected a pointer to 8080, got %v", cfg.Port)
+	}
+	if cfg.Missing != nil {
+		t.Errorf("expected an unset pointer to stay nil, got %v", *cfg.Missing)
+	}
+	if strings.Join(cfg.Hosts, "|") != "a|b|c" {
+		t.Errorf("expected a|b|c, got %v", cfg.Hosts)
+	}
+	if len(cfg.Ports) != 2 || cfg.Ports[0] != 80 || cfg.Ports[1] != 443 {
+		t.Errorf("expected [80 443], got %v", cfg.Ports)
+	}
+	if !cfg.Enabled || cfg.Ratio != 0.5 {
+		t.Errorf("unexpected bool & float %v %v", cfg.Enabled, cfg.Ratio)
+	}
+	if cfg.Level == nil || cfg.Level.name != "info" {
+		t.Errorf("expected a pointer to the info level, got %v", cfg.Level)
+	}
+	if len(cfg.Intervals) != 2 || cfg.Intervals[0] != time.Second || cfg.Intervals[1] != 2*time.Minute {
+		t.Errorf("expected [1s 2m], got %v", cfg.Intervals)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	type config struct {
+		Password string        `env:"TEST_PASSWORD" required:"true"`
+		Timeout  time.Duration `env:"TEST_TIMEOUT"`
+		Level    testLevel     `env:"TEST_LEVEL"`
+		Name     string        `env:"TEST_NAME" required:"true" default:"name"`
+	}
+	t.Setenv("TEST_TIMEOUT", "soon")
+	t.Setenv("TEST_LEVEL", "verbose")
+
+	var cfg config
+	err := Load(context.Background(), &cfg, LoadOpt{})
+	var multiErr *wwgo.MultiError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("expected a MultiError, got %v", err)
+	}
+	msgs := wwgo.JoinErrors(multiErr.Errors(), "\n")
+	if len(multiErr.Errors()) != 3 {
+		t.Fatalf("expected 3 errors, got:\n%s", msgs)
+	}
+	for _, expected := range []string{"TEST_PASSWORD is not set", "TEST_TIMEOUT is invalid", "TEST_LEVEL is invalid"} {
+		if !strings.Contains(msgs, expected) {
+			t.Errorf("expected '%s' in:\n%s", expected, msgs)
+		}
+	}
+	// Valid values are still loaded.
+	if cfg.Name != "name" {
+		t.Errorf("expected the default name, got '%s'", cfg.Name)
+	}
+}
+
+func TestLoadNotAStruct(t *testing.T) {
+	var s string
+	if err := Load(context.Background(), &s, LoadOpt{}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestRedactValue(t *testing.T) {
+	tests := []struct {
+		field    Field
+		expected string
+	}{
+		{Field{Env: "DB_HOST", Value: "localhost"}, "localhost"},
+		{Field{Env: "DB_HOST", Value: "localhost", Secret: true}, "********"},
+		{Field{Env: "DB_PASSWORD", Value: "hunter2"}, "********"},
+		{Field{Env: "stripe_api_key", Value: "sk_test"}, "********"},
+		{Field{Env: "JWT_PRIVATE_KEY", Value: "key"}, "********"},
+		{Field{Env: "DB_PASSWORD", Value: ""}, ""},
+	}
+	for _, test := range tests {
+		if res := RedactValue(test.field); res != test.expected {
+			t.Errorf("%s: expected '%s', got '%s'", test.field.Env, test.expected, res)
+		}
+	}
+}
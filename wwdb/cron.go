@@ -0,0 +1,121 @@
+package wwdb
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/weavingwebs/wwgo"
+	"time"
+)
+
+// CronRunStore is a wwgo.CronRunStore backed by the cron_run table, see
+// cron.sql.
+type CronRunStore struct {
+	Db *sqlx.DB
+}
+
+type cronRunRow struct {
+	Id         uuid.UUID       `db:"id"`
+	Job        string          `db:"job"`
+	Host       string          `db:"host"`
+	StartedAt  time.Time       `db:"startedAt"`
+	EndedAt    wwgo.NullTime   `db:"endedAt"`
+	DurationMs wwgo.NullInt64  `db:"durationMs"`
+	Attempts   int             `db:"attempts"`
+	Error      wwgo.NullString `db:"error"`
+}
+
+func newCronRunRow(run *wwgo.CronRun) cronRunRow {
+	row := cronRunRow{
+		Id:        run.Id,
+		Job:       run.Job,
+		Host:      run.Host,
+		StartedAt: run.StartedAt,
+		Attempts:  run.Attempts,
+		EndedAt:   wwgo.NullFromPtr(run.EndedAt),
+		Error:     wwgo.NullFromPtr(run.Error),
+	}
+	if run.EndedAt != nil {
+		row.DurationMs = wwgo.NewNull(run.Duration.Milliseconds())
+	}
+	return row
+}
+
+func (row cronRunRow) toCronRun() *wwgo.CronRun {
+	return &wwgo.CronRun{
+		Id:        row.Id,
+		Job:       row.Job,
+		Host:      row.Host,
+		StartedAt: row.StartedAt,
+		Attempts:  row.Attempts,
+		EndedAt:   row.EndedAt.Ptr(),
+		Duration:  time.Duration(row.DurationMs.V) * time.Millisecond,
+		Error:     row.Error.Ptr(),
+	}
+}
+
+func (s *CronRunStore) StartRun(ctx context.Context, run *wwgo.CronRun) error {
+	const q = `
+	INSERT INTO cron_run (id, job, host, startedAt)
+	VALUES (:id, :job, :host, :startedAt)
+	`
+	if _, err := s.Db.NamedExecContext(ctx, q, newCronRunRow(run)); err != nil {
+		return errors.Wrapf(err, "failed to insert into cron_run")
+	}
+	return nil
+}
+
+func (s *CronRunStore) EndRun(ctx context.Context, run *wwgo.CronRun) error {
+	const q = `
+	UPDATE cron_run
+	SET endedAt = :endedAt, durationMs = :durationMs, attempts = :attempts, error = :error
+	WHERE id = :id
+	`
+	if _, err := s.Db.NamedExecContext(ctx, q, newCronRunRow(run)); err != nil {
+		return errors.Wrapf(err, "failed to update cron_run")
+	}
+	return nil
+}
+
+func (s *CronRunStore) LastRuns(ctx context.Context, job string, limit int) ([]*wwgo.CronRun, error) {
+	const q = `
+	SELECT id, job, host, startedAt, endedAt, durationMs, attempts, error
+	FROM cron_run
+	WHERE job = ?
+	ORDER BY startedAt DESC
+	LIMIT ?
+	`
+	var rows []cronRunRow
+	if err := s.Db.SelectContext(ctx, &rows, q, job, limit); err != nil {
+		return nil, errors.Wrapf(err, "failed to select from cron_run")
+	}
+	return wwgo.MapSlice(rows, cronRunRow.toCronRun), nil
+}
+
+// CronRunGC deletes runs that started before the given time.
+func CronRunGC(ctx context.Context, dbConn *sqlx.DB, before time.Time) (int64, error) {
+	const q = `DELETE FROM cron_run WHERE startedAt < ?`
+	res, err := dbConn.ExecContext(ctx, q, before)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to GC cron_run")
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		panic(errors.Wrapf(err, "failed to get rows affected"))
+	}
+	return affected, nil
+}
+
+// CronLocker is a wwgo.CronLocker that uses the DB locks (see Lock).
+type CronLocker struct {
+	Db *sqlx.DB
+}
+
+func (l *CronLocker) TryLock(ctx context.Context, name string) (bool, error) {
+	return TryLock(ctx, l.Db, name)
+}
+
+func (l *CronLocker) Unlock(ctx context.Context, name string) error {
+	return Unlock(ctx, name)
+}
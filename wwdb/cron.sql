@@ -0,0 +1,12 @@
+CREATE TABLE cron_run (
+  id BINARY(36) NOT NULL PRIMARY KEY,
+  job VARCHAR(128) NOT NULL,
+  host VARCHAR(255) NOT NULL,
+  startedAt DATETIME(3) NOT NULL,
+  endedAt DATETIME(3) NULL,
+  durationMs BIGINT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  error TEXT NULL
+);
+
+CREATE INDEX cron_run_job ON cron_run (job, startedAt);
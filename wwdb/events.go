@@ -0,0 +1,188 @@
+package wwdb
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"sync"
+	"time"
+)
+
+// EventsBackend is a wwgo.EventsBackend that uses the events table (see
+// events.sql) so that events are delivered across all instances sharing the
+// DB. Listeners poll for events newer than the last one they have seen.
+type EventsBackend struct {
+	Db  *sqlx.DB
+	Log zerolog.Logger
+	// Optional, default 1 second.
+	PollInterval time.Duration
+	// Optional, the maximum number of events read per poll (default 1000).
+	BatchSize int
+	// Optional, how long to wait for a missing id to be committed before
+	// skipping it (default 10 seconds). Concurrent inserts can commit out of
+	// order & failed inserts leave gaps in the ids.
+	GapTimeout time.Duration
+
+	startMut sync.Mutex
+	startId  *uint64
+}
+
+type eventRow struct {
+	Id      uint64 `db:"id"`
+	Name    string `db:"name"`
+	Payload []byte `db:"payload"`
+}
+
+// start returns the id to listen from, which is fixed by the first Publish or
+// Listen so events published before Listen are not missed.
+func (b *EventsBackend) start(ctx context.Context) (uint64, error) {
+	b.startMut.Lock()
+	defer b.startMut.Unlock()
+	if b.startId != nil {
+		return *b.startId, nil
+	}
+	var id uint64
+	if err := b.Db.GetContext(ctx, &id, `SELECT COALESCE(MAX(id), 0) FROM events`); err != nil {
+		return 0, errors.Wrapf(err, "failed to get events cursor")
+	}
+	b.startId = &id
+	return id, nil
+}
+
+func (b *EventsBackend) Publish(ctx context.Context, eventName string, payload []byte) error {
+	if _, err := b.start(ctx); err != nil {
+		return err
+	}
+	const q = `INSERT INTO events (name, payload) VALUES (?, ?)`
+	if _, err := b.Db.ExecContext(ctx, q, eventName, payload); err != nil {
+		return errors.Wrapf(err, "failed to insert into events")
+	}
+	return nil
+}
+
+func (b *EventsBackend) Listen(ctx context.Context, fn func(eventName string, payload []byte)) error {
+	pollInterval := b.PollInterval
+	if pollInterval == 0 {
+		pollInterval = time.Second
+	}
+	batchSize := b.BatchSize
+	if batchSize == 0 {
+		batchSize = 1000
+	}
+	gapTimeout := b.GapTimeout
+	if gapTimeout == 0 {
+		gapTimeout = 10 * time.Second
+	}
+
+	startId, err := b.start(ctx)
+	if err != nil {
+		return err
+	}
+	cursor := newEventsCursor(startId, gapTimeout)
+
+	const q = `SELECT id, name, payload FROM events WHERE id > ? ORDER BY id LIMIT ?`
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		// Read everything after the settled id, skipping the events we have
+		// already seen, until we have caught up.
+		after := cursor.settled
+		for {
+			var rows []eventRow
+			if err := b.Db.SelectContext(ctx, &rows, q, after, batchSize); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				// Don't die on a DB blip, just try again next time.
+				b.Log.Err(err).Msg("Failed to poll events")
+				break
+			}
+			for _, row := range rows {
+				if cursor.see(row.Id) {
+					fn(row.Name, row.Payload)
+				}
+				after = row.Id
+			}
+			if len(rows) < batchSize {
+				break
+			}
+		}
+		cursor.advance(time.Now())
+	}
+}
+
+// eventsCursor tracks the events seen after the settled id, the id below
+// which every event has been seen or given up on.
+type eventsCursor struct {
+	settled    uint64
+	seen       map[uint64]bool
+	gapTimeout time.Duration
+	// When the settled id first stopped at the current gap.
+	gapSince time.Time
+}
+
+func newEventsCursor(settled uint64, gapTimeout time.Duration) *eventsCursor {
+	return &eventsCursor{
+		settled:    settled,
+		seen:       map[uint64]bool{},
+		gapTimeout: gapTimeout,
+	}
+}
+
+// see returns false if the event has already been seen.
+func (c *eventsCursor) see(id uint64) bool {
+	if id <= c.settled || c.seen[id] {
+		return false
+	}
+	c.seen[id] = true
+	return true
+}
+
+// advance moves the settled id past the seen events, & past a gap once it has
+// been waited on for the gapTimeout.
+func (c *eventsCursor) advance(now time.Time) {
+	for len(c.seen) != 0 {
+		next := c.settled + 1
+		if c.seen[next] {
+			delete(c.seen, next)
+			c.settled = next
+			c.gapSince = time.Time{}
+			continue
+		}
+		if c.gapSince.IsZero() {
+			c.gapSince = now
+		}
+		if now.Sub(c.gapSince) < c.gapTimeout {
+			return
+		}
+		// Give up on the ids before the lowest seen id.
+		lowest := uint64(0)
+		for id := range c.seen {
+			if lowest == 0 || id < lowest {
+				lowest = id
+			}
+		}
+		c.settled = lowest - 1
+	}
+}
+
+// EventsGC deletes events older than the given age.
+func EventsGC(ctx context.Context, dbConn *sqlx.DB, maxAge time.Duration) (int64, error) {
+	const q = `DELETE FROM events WHERE timestamp < ?`
+	res, err := dbConn.ExecContext(ctx, q, time.Now().Add(-maxAge))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to GC events")
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		panic(errors.Wrapf(err, "failed to get rows affected"))
+	}
+	return affected, nil
+}
@@ -0,0 +1,8 @@
+CREATE TABLE events (
+  id BIGINT UNSIGNED AUTO_INCREMENT NOT NULL PRIMARY KEY,
+  name VARCHAR(128) NOT NULL,
+  payload LONGBLOB NOT NULL,
+  timestamp DATETIME(3) DEFAULT NOW(3) NOT NULL
+);
+
+CREATE INDEX events_purge ON events (timestamp);
@@ -0,0 +1,53 @@
+package wwdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventsCursorOutOfOrder(t *testing.T) {
+	now := time.Now()
+	c := newEventsCursor(10, 10*time.Second)
+
+	// 12 commits before 11.
+	if !c.see(12) {
+		t.Fatal("expected 12 to be new")
+	}
+	c.advance(now)
+	if c.settled != 10 {
+		t.Fatalf("expected to wait for 11, settled %d", c.settled)
+	}
+
+	// 12 is read again whilst waiting.
+	if c.see(12) {
+		t.Fatal("expected 12 to be deduplicated")
+	}
+	if !c.see(11) {
+		t.Fatal("expected 11 to be new")
+	}
+	c.advance(now.Add(time.Second))
+	if c.settled != 12 || len(c.seen) != 0 {
+		t.Fatalf("expected settled 12 with nothing pending, got %d %v", c.settled, c.seen)
+	}
+}
+
+func TestEventsCursorGapTimeout(t *testing.T) {
+	now := time.Now()
+	c := newEventsCursor(10, 10*time.Second)
+
+	// 11-13 were rolled back.
+	c.see(14)
+	c.see(15)
+	c.advance(now)
+	c.advance(now.Add(5 * time.Second))
+	if c.settled != 10 {
+		t.Fatalf("expected to still wait for the gap, settled %d", c.settled)
+	}
+	c.advance(now.Add(11 * time.Second))
+	if c.settled != 15 || len(c.seen) != 0 {
+		t.Fatalf("expected the gap to be skipped, got %d %v", c.settled, c.seen)
+	}
+	if c.see(14) {
+		t.Fatal("expected settled ids to be ignored")
+	}
+}
@@ -2,6 +2,9 @@ package wwdb
 
 import (
 	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/olekukonko/tablewriter"
@@ -11,11 +14,160 @@ import (
 	"time"
 )
 
+// FloodStore stores flood hits, see MysqlFloodStore, MemoryFloodStore &
+// RespFloodStore.
+type FloodStore interface {
+	// Register records a hit, which expires after the window.
+	Register(ctx context.Context, event string, identifier string, window time.Duration) error
+	// Hit atomically counts the hits within the window & registers a hit if
+	// there are fewer than threshold. If not allowed, retryAfter is how long
+	// until a hit will be allowed.
+	Hit(ctx context.Context, event string, identifier string, window time.Duration, threshold int) (allowed bool, retryAfter time.Duration, err error)
+	// Count returns the number of hits within the window.
+	Count(ctx context.Context, event string, identifier string, window time.Duration) (int, error)
+	// Clear deletes the hits for the identifier.
+	Clear(ctx context.Context, event string, identifier string) error
+	// Empty deletes all hits for the event.
+	Empty(ctx context.Context, event string) error
+	// Unban deletes the hits for the identifier for all events, returning the
+	// number of hits deleted.
+	Unban(ctx context.Context, identifier string) (int64, error)
+	// GC deletes expired hits, returning the number of hits deleted.
+	GC(ctx context.Context) (int64, error)
+	// Summary returns the unexpired hits, grouped by event & identifier,
+	// ordered by the last hit.
+	Summary(ctx context.Context) ([]*FloodSummaryItem, error)
+}
+
 type Flood struct {
-	Db        *sqlx.DB
+	// Required if Store is not set.
+	Db *sqlx.DB
+	// Optional, defaults to MysqlFloodStore with Db.
+	Store     FloodStore
 	Name      string
 	Window    time.Duration
 	Threshold int
+	// Optional, bans & allow-list entries are checked before the store, i.e.
+	// the MysqlFloodStore (see flood_ban.sql).
+	Bans FloodBanStore
+	// Optional, requires Bans. Identifiers that exceed the threshold are banned
+	// for each duration in turn, repeating the last, 0 is permanent i.e.
+	// []time.Duration{15 * time.Minute, time.Hour, 24 * time.Hour}.
+	BanDurations []time.Duration
+	// Optional, how long previous bans count towards escalation, defaults to
+	// 30 days.
+	BanMemory time.Duration
+}
+
+func (f *Flood) store() FloodStore {
+	if f.Store != nil {
+		return f.Store
+	}
+	return &MysqlFloodStore{Db: f.Db}
+}
+
+// Hit registers a hit if the identifier is allowed, as one atomic operation.
+// If not allowed, retryAfter is how long until it will be.
+func (f *Flood) Hit(ctx context.Context, identifier string) (allowed bool, retryAfter time.Duration, err error) {
+	ban, err := f.checkBans(ctx, identifier)
+	if err != nil {
+		return false, 0, err
+	}
+	if ban != nil {
+		if ban.Kind == FloodBanKindAllow {
+			return true, 0, nil
+		}
+		return false, f.banRetryAfter(ban, time.Now()), nil
+	}
+
+	allowed, retryAfter, err = f.store().Hit(ctx, f.Name, identifier, f.Window, f.Threshold)
+	if err != nil || allowed || f.Bans == nil || len(f.BanDurations) == 0 {
+		return allowed, retryAfter, err
+	}
+	ban, err = f.escalate(ctx, identifier)
+	if err != nil {
+		return false, 0, err
+	}
+	// The hits are cleared by the ban, so it is retried after the ban.
+	return false, f.banRetryAfter(ban, time.Now()), nil
+}
+
+// Register panics on error, see TryRegister.
+func (f *Flood) Register(ctx context.Context, identifier string) {
+	if err := f.TryRegister(ctx, identifier); err != nil {
+		panic(err)
+	}
+}
+
+func (f *Flood) TryRegister(ctx context.Context, identifier string) error {
+	return f.store().Register(ctx, f.Name, identifier, f.Window)
+}
+
+func (f *Flood) Clear(ctx context.Context, identifier string) error {
+	return f.store().Clear(ctx, f.Name, identifier)
+}
+
+func (f *Flood) Empty(ctx context.Context) error {
+	return f.store().Empty(ctx, f.Name)
+}
+
+// IsAllowed panics on error, see TryIsAllowed. Use Hit to check & register
+// atomically.
+func (f *Flood) IsAllowed(ctx context.Context, identifier string) bool {
+	allowed, err := f.TryIsAllowed(ctx, identifier)
+	if err != nil {
+		panic(err)
+	}
+	return allowed
+}
+
+func (f *Flood) TryIsAllowed(ctx context.Context, identifier string) (bool, error) {
+	ban, err := f.checkBans(ctx, identifier)
+	if err != nil {
+		return false, err
+	}
+	if ban != nil {
+		return ban.Kind == FloodBanKindAllow, nil
+	}
+	count, err := f.store().Count(ctx, f.Name, identifier, f.Window)
+	if err != nil {
+		return false, err
+	}
+	return count < f.Threshold, nil
+}
+
+// Count returns the number of hits within the window.
+func (f *Flood) Count(ctx context.Context, identifier string) (int, error) {
+	return f.store().Count(ctx, f.Name, identifier, f.Window)
+}
+
+// floodRetryAfter returns how long until there are fewer than threshold hits
+// in the window, timestamps must be the hits in the window, oldest first.
+func floodRetryAfter(timestamps []time.Time, window time.Duration, threshold int, now time.Time) time.Duration {
+	if len(timestamps) < threshold {
+		return 0
+	}
+	if threshold <= 0 {
+		return window
+	}
+	retryAfter := timestamps[len(timestamps)-threshold].Add(window).Sub(now)
+	if retryAfter < 0 {
+		return 0
+	}
+	return retryAfter
+}
+
+type FloodSummaryItem struct {
+	Event      string    `db:"event" json:"event"`
+	Identifier string    `db:"identifier" json:"identifier"`
+	Count      int       `db:"count" json:"count"`
+	LastHit    time.Time `db:"lastHit" json:"lastHit"`
+}
+
+// MysqlFloodStore is a FloodStore backed by the flood table, see flood.sql,
+// & a FloodBanStore backed by the flood_ban table, see flood_ban.sql.
+type MysqlFloodStore struct {
+	Db *sqlx.DB
 }
 
 type FloodHit struct {
@@ -25,66 +177,149 @@ type FloodHit struct {
 	Expiration time.Time `db:"expiration"`
 }
 
-func (f *Flood) Register(ctx context.Context, identifier string) {
+func (s *MysqlFloodStore) Register(ctx context.Context, event string, identifier string, window time.Duration) error {
 	const q = `
 	INSERT INTO flood (event, identifier, timestamp, expiration)
 	VALUES (:event, :identifier, :timestamp, :expiration)
 	`
 	now := time.Now()
-	_, err := f.Db.NamedExecContext(ctx, q, FloodHit{
-		Event:      f.Name,
+	_, err := s.Db.NamedExecContext(ctx, q, FloodHit{
+		Event:      event,
 		Identifier: identifier,
 		Timestamp:  now,
-		Expiration: now.Add(f.Window),
+		Expiration: now.Add(window),
 	})
 	if err != nil {
-		panic(errors.Wrapf(err, "failed to insert into flood"))
+		return errors.Wrapf(err, "failed to insert into flood")
 	}
+	return nil
 }
 
-func (f *Flood) Clear(ctx context.Context, identifier string) error {
-	const q = `DELETE FROM flood WHERE event = ? AND identifier = ?`
-	_, err := f.Db.ExecContext(ctx, q, f.Name, identifier)
+// Hit serialises concurrent hits for the same identifier with a named lock,
+// which is held until the hit is committed.
+func (s *MysqlFloodStore) Hit(ctx context.Context, event string, identifier string, window time.Duration, threshold int) (bool, time.Duration, error) {
+	// Take the lock on the transaction's connection, to release it after the
+	// commit.
+	conn, err := s.Db.Connx(ctx)
 	if err != nil {
-		return errors.Wrapf(err, "failed to delete from flood")
+		return false, 0, errors.Wrapf(err, "failed to get flood connection")
 	}
-	return nil
+	defer func() { _ = conn.Close() }()
+	unlock, err := lockFlood(ctx, conn, event, identifier)
+	if err != nil {
+		return false, 0, err
+	}
+	defer unlock()
+
+	// Read committed so the hits committed by the previous lock holder are
+	// seen.
+	tx, err := conn.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return false, 0, errors.Wrapf(err, "failed to begin flood transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+	allowed, retryAfter, err := hitFloodTx(ctx, tx, event, identifier, window, threshold, false)
+	if err != nil {
+		return false, 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, 0, errors.Wrapf(err, "failed to commit flood transaction")
+	}
+	return allowed, retryAfter, nil
 }
 
-func (f *Flood) Empty(ctx context.Context) error {
-	const q = `DELETE FROM flood WHERE event = ?`
-	_, err := f.Db.ExecContext(ctx, q, f.Name)
+// HitTx is Hit within an existing transaction. A named lock can't be held
+// until the caller commits, so the hits are read with a locking read (FOR
+// UPDATE) instead of the transaction's snapshot, which may be stale under the
+// default REPEATABLE READ isolation. Under REPEATABLE READ the gap locks block
+// concurrent hits for the identifier until the transaction ends & may cause
+// deadlock errors, under READ COMMITTED there are no gap locks so concurrent
+// hits for an identifier without hits in the window may both be allowed.
+func (s *MysqlFloodStore) HitTx(ctx context.Context, tx *sqlx.Tx, event string, identifier string, window time.Duration, threshold int) (bool, time.Duration, error) {
+	return hitFloodTx(ctx, tx, event, identifier, window, threshold, true)
+}
+
+// lockFlood takes a named lock for the identifier on the connection,
+// returning a func to release it.
+func lockFlood(ctx context.Context, conn *sqlx.Conn, event string, identifier string) (func(), error) {
+	// GET_LOCK names are limited to 64 characters.
+	lockName := fmt.Sprintf("flood:%x", sha1.Sum([]byte(event+"\x00"+identifier)))
+	var locked sql.NullBool
+	if err := conn.GetContext(ctx, &locked, `SELECT GET_LOCK(?, 10)`, lockName); err != nil {
+		return nil, errors.Wrapf(err, "failed to lock flood")
+	}
+	if !locked.Bool {
+		return nil, errors.Errorf("timed out waiting for flood lock")
+	}
+	return func() {
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), `SELECT RELEASE_LOCK(?)`, lockName)
+	}, nil
+}
+
+func hitFloodTx(ctx context.Context, tx *sqlx.Tx, event string, identifier string, window time.Duration, threshold int, forUpdate bool) (bool, time.Duration, error) {
+	q := `
+	SELECT timestamp FROM flood
+	WHERE event = ? AND identifier = ? AND timestamp > ?
+	ORDER BY timestamp
+	`
+	if forUpdate {
+		q += `FOR UPDATE`
+	}
+	now := time.Now()
+	var timestamps []time.Time
+	if err := tx.SelectContext(ctx, &timestamps, q, event, identifier, now.Add(-window)); err != nil {
+		return false, 0, errors.Wrapf(err, "failed to query flood")
+	}
+	if len(timestamps) >= threshold {
+		return false, floodRetryAfter(timestamps, window, threshold, now), nil
+	}
+
+	const insertQ = `
+	INSERT INTO flood (event, identifier, timestamp, expiration)
+	VALUES (:event, :identifier, :timestamp, :expiration)
+	`
+	_, err := tx.NamedExecContext(ctx, insertQ, FloodHit{
+		Event:      event,
+		Identifier: identifier,
+		Timestamp:  now,
+		Expiration: now.Add(window),
+	})
 	if err != nil {
-		return errors.Wrapf(err, "failed to delete all from flood")
+		return false, 0, errors.Wrapf(err, "failed to insert into flood")
 	}
-	return nil
+	return true, 0, nil
 }
 
-func (f *Flood) IsAllowed(ctx context.Context, identifier string) bool {
+func (s *MysqlFloodStore) Count(ctx context.Context, event string, identifier string, window time.Duration) (int, error) {
 	const q = `SELECT COUNT(*) FROM flood WHERE event = ? AND identifier = ? AND timestamp > ?`
 	var count int
-	if err := f.Db.GetContext(ctx, &count, q, f.Name, identifier, time.Now().Add(-f.Window)); err != nil {
-		panic(errors.Wrapf(err, "failed to query flood"))
+	if err := s.Db.GetContext(ctx, &count, q, event, identifier, time.Now().Add(-window)); err != nil {
+		return 0, errors.Wrapf(err, "failed to query flood")
 	}
-	return count < f.Threshold
+	return count, nil
 }
 
-func FloodGC(ctx context.Context, dbConn *sqlx.DB) (int64, error) {
-	const q = `DELETE FROM flood WHERE expiration < ?`
-	res, err := dbConn.ExecContext(ctx, q, time.Now())
+func (s *MysqlFloodStore) Clear(ctx context.Context, event string, identifier string) error {
+	const q = `DELETE FROM flood WHERE event = ? AND identifier = ?`
+	_, err := s.Db.ExecContext(ctx, q, event, identifier)
 	if err != nil {
-		return 0, errors.Wrapf(err, "failed to GC flood")
+		return errors.Wrapf(err, "failed to delete from flood")
 	}
-	affected, err := res.RowsAffected()
+	return nil
+}
+
+func (s *MysqlFloodStore) Empty(ctx context.Context, event string) error {
+	const q = `DELETE FROM flood WHERE event = ?`
+	_, err := s.Db.ExecContext(ctx, q, event)
 	if err != nil {
-		panic(errors.Wrapf(err, "failed to get rows affected"))
+		return errors.Wrapf(err, "failed to delete all from flood")
 	}
-	return affected, nil
+	return nil
 }
 
-func FloodUnban(ctx context.Context, dbConn *sqlx.DB, identifier string) (int64, error) {
+func (s *MysqlFloodStore) Unban(ctx context.Context, identifier string) (int64, error) {
 	const q = `DELETE FROM flood WHERE identifier = ?`
-	res, err := dbConn.ExecContext(ctx, q, identifier)
+	res, err := s.Db.ExecContext(ctx, q, identifier)
 	if err != nil {
 		return 0, errors.Wrapf(err, "failed to delete from flood")
 	}
@@ -95,14 +330,20 @@ func FloodUnban(ctx context.Context, dbConn *sqlx.DB, identifier string) (int64,
 	return affected, nil
 }
 
-type FloodSummaryItem struct {
-	Event      string    `db:"event"`
-	Identifier string    `db:"identifier"`
-	Count      int       `db:"count"`
-	LastHit    time.Time `db:"lastHit"`
+func (s *MysqlFloodStore) GC(ctx context.Context) (int64, error) {
+	const q = `DELETE FROM flood WHERE expiration < ?`
+	res, err := s.Db.ExecContext(ctx, q, time.Now())
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to GC flood")
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		panic(errors.Wrapf(err, "failed to get rows affected"))
+	}
+	return affected, nil
 }
 
-func FloodGetSummary(ctx context.Context, dbConn *sqlx.DB) ([]*FloodSummaryItem, error) {
+func (s *MysqlFloodStore) Summary(ctx context.Context) ([]*FloodSummaryItem, error) {
 	const q = `
 	SELECT event, identifier, COUNT(*) as count, MAX(timestamp) as lastHit
 	FROM flood
@@ -112,13 +353,44 @@ func FloodGetSummary(ctx context.Context, dbConn *sqlx.DB) ([]*FloodSummaryItem,
 	`
 
 	var res []*FloodSummaryItem
-	if err := dbConn.SelectContext(ctx, &res, q, time.Now()); err != nil {
+	if err := s.Db.SelectContext(ctx, &res, q, time.Now()); err != nil {
 		return nil, errors.Wrapf(err, "failed to select")
 	}
 	return res, nil
 }
 
+func FloodGC(ctx context.Context, dbConn *sqlx.DB) (int64, error) {
+	return (&MysqlFloodStore{Db: dbConn}).GC(ctx)
+}
+
+func FloodUnban(ctx context.Context, dbConn *sqlx.DB, identifier string) (int64, error) {
+	return (&MysqlFloodStore{Db: dbConn}).Unban(ctx, identifier)
+}
+
+func FloodGetSummary(ctx context.Context, dbConn *sqlx.DB) ([]*FloodSummaryItem, error) {
+	return (&MysqlFloodStore{Db: dbConn}).Summary(ctx)
+}
+
+// FloodCommand requires flood_ban.sql for the ban subcommands & unban.
 func FloodCommand(dbConn func() *sqlx.DB) *cli.Command {
+	return FloodStoreCommand(func() FloodStore {
+		return &MysqlFloodStore{Db: dbConn()}
+	})
+}
+
+var floodJsonFlag = &cli.BoolFlag{
+	Name:  "json",
+	Usage: "Output JSON",
+}
+
+var floodEventFlag = &cli.StringFlag{
+	Name:  "event",
+	Usage: "Only this event (default all events)",
+}
+
+// FloodStoreCommand is FloodCommand for any FloodStore, the ban subcommands
+// require a FloodBanStore.
+func FloodStoreCommand(store func() FloodStore) *cli.Command {
 	return &cli.Command{
 		Name:  "flood",
 		Usage: "Flood commands",
@@ -126,11 +398,18 @@ func FloodCommand(dbConn func() *sqlx.DB) *cli.Command {
 			{
 				Name:  "get",
 				Usage: "Get a summary of flood hits",
+				Flags: []cli.Flag{floodJsonFlag},
 				Action: func(ctx *cli.Context) error {
-					summary, err := FloodGetSummary(ctx.Context, dbConn())
+					summary, err := store().Summary(ctx.Context)
 					if err != nil {
 						return err
 					}
+					if ctx.Bool("json") {
+						if summary == nil {
+							summary = []*FloodSummaryItem{}
+						}
+						return printFloodJson(summary)
+					}
 
 					if len(summary) == 0 {
 						fmt.Printf("Flood table is empty\n")
@@ -158,22 +437,206 @@ func FloodCommand(dbConn func() *sqlx.DB) *cli.Command {
 				},
 			},
 			{
-				Name:  "unban",
-				Usage: "Unban an IP",
+				Name:      "unban",
+				Usage:     "Delete the flood hits & bans for an identifier",
+				ArgsUsage: "<identifier>",
+				Flags:     []cli.Flag{floodEventFlag, floodJsonFlag},
 				Action: func(ctx *cli.Context) error {
-					if ctx.NArg() < 1 {
-						fmt.Println("Argument required: ip address")
-						os.Exit(1)
+					identifier := floodIdentifierArg(ctx)
+					event := ctx.String("event")
+					floodStore := store()
+					res := struct {
+						Hits int64 `json:"hits"`
+						Bans int64 `json:"bans"`
+					}{}
+					var err error
+					if event != "" {
+						// Clear does not report the number deleted.
+						if res.Hits, err = floodCount(ctx, floodStore, event, identifier); err != nil {
+							return err
+						}
+						if err := floodStore.Clear(ctx.Context, event, identifier); err != nil {
+							return err
+						}
+					} else {
+						if res.Hits, err = floodStore.Unban(ctx.Context, identifier); err != nil {
+							return err
+						}
+					}
+					// Bans are optional, i.e. the flood_ban table may not exist.
+					if banStore, ok := floodStore.(FloodBanStore); ok {
+						res.Bans, err = banStore.RemoveBans(ctx.Context, FloodBanKindBan, event, identifier)
+						if err != nil && !isMysqlNoSuchTable(err) {
+							return err
+						}
+					}
+
+					if ctx.Bool("json") {
+						return printFloodJson(res)
 					}
-					ip := ctx.Args().Get(0)
-					res, err := FloodUnban(ctx.Context, dbConn(), ip)
+					fmt.Printf("%d entries deleted, %d bans removed\n", res.Hits, res.Bans)
+					return nil
+				},
+			},
+			{
+				Name:      "ban",
+				Usage:     "Ban an identifier, IP or CIDR",
+				ArgsUsage: "<identifier>",
+				Flags:     floodBanFlags(),
+				Action: func(ctx *cli.Context) error {
+					return floodAddBan(ctx, store(), FloodBanKindBan)
+				},
+			},
+			{
+				Name:      "allow",
+				Usage:     "Allow-list an identifier, IP or CIDR, it will never be flood limited or banned",
+				ArgsUsage: "<identifier>",
+				Flags: append(floodBanFlags(), &cli.BoolFlag{
+					Name:  "remove",
+					Usage: "Remove the identifier from the allow-list",
+				}),
+				Action: func(ctx *cli.Context) error {
+					if !ctx.Bool("remove") {
+						return floodAddBan(ctx, store(), FloodBanKindAllow)
+					}
+					banStore, err := floodBanStore(store())
+					if err != nil {
+						return err
+					}
+					deleted, err := banStore.RemoveBans(ctx.Context, FloodBanKindAllow, ctx.String("event"), floodIdentifierArg(ctx))
 					if err != nil {
 						return err
 					}
-					fmt.Printf("%d entries deleted\n", res)
+					if ctx.Bool("json") {
+						return printFloodJson(map[string]int64{"removed": deleted})
+					}
+					fmt.Printf("%d entries removed\n", deleted)
+					return nil
+				},
+			},
+			{
+				Name:  "list-bans",
+				Usage: "List the active bans & allow-list",
+				Flags: []cli.Flag{floodJsonFlag},
+				Action: func(ctx *cli.Context) error {
+					banStore, err := floodBanStore(store())
+					if err != nil {
+						return err
+					}
+					bans, err := banStore.ListBans(ctx.Context)
+					if err != nil {
+						return err
+					}
+					if ctx.Bool("json") {
+						if bans == nil {
+							bans = []*FloodBan{}
+						}
+						return printFloodJson(bans)
+					}
+
+					if len(bans) == 0 {
+						fmt.Printf("There are no bans\n")
+						return nil
+					}
+
+					table := tablewriter.NewWriter(os.Stdout)
+					table.SetHeader([]string{
+						"Kind",
+						"Event",
+						"Identifier",
+						"Reason",
+						"Created",
+						"Expires",
+					})
+					for _, ban := range bans {
+						event := ban.Event
+						if event == "" {
+							event = "*"
+						}
+						expires := "Never"
+						if ban.Expiration.Valid {
+							expires = ban.Expiration.V.Format(time.RFC822)
+						}
+						table.Append([]string{
+							string(ban.Kind),
+							event,
+							ban.Identifier,
+							ban.Reason,
+							ban.Created.Format(time.RFC822),
+							expires,
+						})
+					}
+					table.Render()
 					return nil
 				},
 			},
 		},
 	}
 }
+
+func floodBanFlags() []cli.Flag {
+	return []cli.Flag{
+		floodEventFlag,
+		&cli.DurationFlag{
+			Name:  "duration",
+			Usage: "i.e. 24h (default permanent)",
+		},
+		&cli.StringFlag{
+			Name:  "reason",
+			Usage: "A note on why",
+		},
+		floodJsonFlag,
+	}
+}
+
+func floodAddBan(ctx *cli.Context, store FloodStore, kind FloodBanKind) error {
+	banStore, err := floodBanStore(store)
+	if err != nil {
+		return err
+	}
+	identifier := floodIdentifierArg(ctx)
+	if err := ValidateFloodBanIdentifier(identifier); err != nil {
+		return err
+	}
+	ban := NewFloodBan(kind, ctx.String("event"), identifier, ctx.Duration("duration"), ctx.String("reason"))
+	if err := banStore.AddBan(ctx.Context, ban); err != nil {
+		return err
+	}
+	if ctx.Bool("json") {
+		return printFloodJson(ban)
+	}
+	if kind == FloodBanKindAllow {
+		fmt.Printf("%s allowed\n", identifier)
+	} else {
+		fmt.Printf("%s banned\n", identifier)
+	}
+	return nil
+}
+
+func floodIdentifierArg(ctx *cli.Context) string {
+	if ctx.NArg() < 1 {
+		fmt.Println("Argument required: identifier or ip address")
+		os.Exit(1)
+	}
+	return ctx.Args().Get(0)
+}
+
+// floodCount counts every unexpired hit, the summary has no window.
+func floodCount(ctx *cli.Context, store FloodStore, event string, identifier string) (int64, error) {
+	summary, err := store.Summary(ctx.Context)
+	if err != nil {
+		return 0, err
+	}
+	for _, item := range summary {
+		if item.Event == event && item.Identifier == identifier {
+			return int64(item.Count), nil
+		}
+	}
+	return 0, nil
+}
+
+func printFloodJson(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,291 @@
+package wwdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/weavingwebs/wwgo"
+	"net"
+	"strings"
+	"time"
+)
+
+type FloodBanKind string
+
+const (
+	FloodBanKindBan   FloodBanKind = "ban"
+	FloodBanKindAllow FloodBanKind = "allow"
+)
+
+// FloodBan bans or allow-lists an identifier, allow-list entries take
+// priority over bans & the flood.
+type FloodBan struct {
+	Id   uuid.UUID    `db:"id" json:"id"`
+	Kind FloodBanKind `db:"kind" json:"kind"`
+	// Empty for all events.
+	Event string `db:"event" json:"event"`
+	// An identifier, IPs also match a CIDR i.e. "10.0.0.0/8".
+	Identifier string    `db:"identifier" json:"identifier"`
+	Reason     string    `db:"reason" json:"reason"`
+	Created    time.Time `db:"created" json:"created"`
+	// Null for a permanent ban.
+	Expiration wwgo.NullTime `db:"expiration" json:"expiration"`
+}
+
+func NewFloodBan(kind FloodBanKind, event string, identifier string, duration time.Duration, reason string) *FloodBan {
+	now := time.Now()
+	ban := &FloodBan{
+		Id:         uuid.New(),
+		Kind:       kind,
+		Event:      event,
+		Identifier: identifier,
+		Reason:     reason,
+		Created:    now,
+	}
+	if duration > 0 {
+		ban.Expiration = wwgo.NewNull(now.Add(duration))
+	}
+	return ban
+}
+
+// Matches returns true if the ban applies to the event & identifier, it does
+// not check the expiration.
+func (b *FloodBan) Matches(event string, identifier string) bool {
+	if b.Event != "" && b.Event != event {
+		return false
+	}
+	if b.Identifier == identifier {
+		return true
+	}
+	ip := net.ParseIP(identifier)
+	if ip == nil {
+		return false
+	}
+	if strings.Contains(b.Identifier, "/") {
+		_, ipNet, err := net.ParseCIDR(b.Identifier)
+		return err == nil && ipNet.Contains(ip)
+	}
+	banIp := net.ParseIP(b.Identifier)
+	return banIp != nil && banIp.Equal(ip)
+}
+
+func (b *FloodBan) IsExpired(now time.Time) bool {
+	return b.Expiration.Valid && !b.Expiration.V.After(now)
+}
+
+// ValidateFloodBanIdentifier checks CIDRs are valid, as they would otherwise
+// never match.
+func ValidateFloodBanIdentifier(identifier string) error {
+	if identifier == "" {
+		return errors.Errorf("identifier is required")
+	}
+	if strings.Contains(identifier, "/") && net.ParseIP(strings.Split(identifier, "/")[0]) != nil {
+		if _, _, err := net.ParseCIDR(identifier); err != nil {
+			return errors.Wrapf(err, "invalid CIDR")
+		}
+	}
+	return nil
+}
+
+// FloodBanStore stores bans & allow-list entries, see Flood.Bans. It is
+// implemented by MysqlFloodStore & MemoryFloodStore.
+type FloodBanStore interface {
+	AddBan(ctx context.Context, ban *FloodBan) error
+	// MatchBans returns the unexpired bans & allow-list entries for the event
+	// & identifier, see FloodBan.Matches.
+	MatchBans(ctx context.Context, event string, identifier string) ([]*FloodBan, error)
+	// Escalate atomically adds the ban from newBan, given the number of bans
+	// (including expired) for the exact event & identifier since the time. If
+	// there is already an unexpired ban for them, i.e. from a concurrent hit,
+	// it is returned instead.
+	Escalate(ctx context.Context, event string, identifier string, since time.Time, newBan func(offences int) *FloodBan) (*FloodBan, error)
+	// ListBans returns the unexpired bans & allow-list entries, newest first.
+	ListBans(ctx context.Context) ([]*FloodBan, error)
+	// RemoveBans deletes the entries of the kind for the exact identifier, for
+	// the event if it is not empty, returning the number deleted.
+	RemoveBans(ctx context.Context, kind FloodBanKind, event string, identifier string) (int64, error)
+}
+
+// matchFloodBan returns the allow-list entry if there is one, otherwise the
+// ban that expires last.
+func matchFloodBan(bans []*FloodBan) *FloodBan {
+	var res *FloodBan
+	for _, ban := range bans {
+		if ban.Kind == FloodBanKindAllow {
+			return ban
+		}
+		if res == nil || !ban.Expiration.Valid || (res.Expiration.Valid && ban.Expiration.V.After(res.Expiration.V)) {
+			res = ban
+		}
+	}
+	return res
+}
+
+// checkBans returns the matching ban or allow-list entry, if any.
+func (f *Flood) checkBans(ctx context.Context, identifier string) (*FloodBan, error) {
+	if f.Bans == nil {
+		return nil, nil
+	}
+	bans, err := f.Bans.MatchBans(ctx, f.Name, identifier)
+	if err != nil {
+		return nil, err
+	}
+	return matchFloodBan(bans), nil
+}
+
+// banRetryAfter returns how long until the ban expires, permanent bans return
+// the window.
+func (f *Flood) banRetryAfter(ban *FloodBan, now time.Time) time.Duration {
+	if !ban.Expiration.Valid {
+		return f.Window
+	}
+	return max(ban.Expiration.V.Sub(now), 0)
+}
+
+// escalate bans the identifier for the next of the BanDurations.
+func (f *Flood) escalate(ctx context.Context, identifier string) (*FloodBan, error) {
+	memory := f.BanMemory
+	if memory == 0 {
+		memory = 30 * 24 * time.Hour
+	}
+	ban, err := f.Bans.Escalate(ctx, f.Name, identifier, time.Now().Add(-memory), func(offences int) *FloodBan {
+		duration := f.BanDurations[min(offences, len(f.BanDurations)-1)]
+		reason := fmt.Sprintf("Exceeded %d hits in %s (offence %d)", f.Threshold, f.Window, offences+1)
+		return NewFloodBan(FloodBanKindBan, f.Name, identifier, duration, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Forget the hits that caused the ban, otherwise the first hit after a ban
+	// shorter than the window would be banned again.
+	if err := f.store().Clear(ctx, f.Name, identifier); err != nil {
+		return nil, err
+	}
+	return ban, nil
+}
+
+func (s *MysqlFloodStore) AddBan(ctx context.Context, ban *FloodBan) error {
+	const q = `
+	INSERT INTO flood_ban (id, kind, event, identifier, reason, created, expiration)
+	VALUES (:id, :kind, :event, :identifier, :reason, :created, :expiration)
+	`
+	if _, err := s.Db.NamedExecContext(ctx, q, ban); err != nil {
+		return errors.Wrapf(err, "failed to insert into flood_ban")
+	}
+	return nil
+}
+
+// MatchBans selects the unexpired entries for the event & filters them in Go
+// for CIDR matching, the table is expected to be small.
+func (s *MysqlFloodStore) MatchBans(ctx context.Context, event string, identifier string) ([]*FloodBan, error) {
+	const q = `
+	SELECT * FROM flood_ban
+	WHERE event IN ('', ?) AND (expiration IS NULL OR expiration > ?)
+	`
+	var bans []*FloodBan
+	if err := s.Db.SelectContext(ctx, &bans, q, event, time.Now()); err != nil {
+		return nil, errors.Wrapf(err, "failed to query flood_ban")
+	}
+	return wwgo.FilterSlice(bans, func(ban *FloodBan) bool {
+		return ban.Matches(event, identifier)
+	}), nil
+}
+
+// Escalate is serialised with the same named lock as Hit.
+func (s *MysqlFloodStore) Escalate(ctx context.Context, event string, identifier string, since time.Time, newBan func(offences int) *FloodBan) (*FloodBan, error) {
+	conn, err := s.Db.Connx(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get flood connection")
+	}
+	defer func() { _ = conn.Close() }()
+	unlock, err := lockFlood(ctx, conn, event, identifier)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	const existingQ = `
+	SELECT * FROM flood_ban
+	WHERE kind = ? AND event = ? AND identifier = ? AND (expiration IS NULL OR expiration > ?)
+	ORDER BY created DESC
+	LIMIT 1
+	`
+	existing := &FloodBan{}
+	err = conn.GetContext(ctx, existing, existingQ, FloodBanKindBan, event, identifier, time.Now())
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Wrapf(err, "failed to query flood_ban")
+	}
+
+	const countQ = `
+	SELECT COUNT(*) FROM flood_ban
+	WHERE kind = ? AND event = ? AND identifier = ? AND created > ?
+	`
+	var offences int
+	if err := conn.GetContext(ctx, &offences, countQ, FloodBanKindBan, event, identifier, since); err != nil {
+		return nil, errors.Wrapf(err, "failed to count flood_ban")
+	}
+
+	const insertQ = `
+	INSERT INTO flood_ban (id, kind, event, identifier, reason, created, expiration)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	ban := newBan(offences)
+	_, err = conn.ExecContext(ctx, insertQ, ban.Id, ban.Kind, ban.Event, ban.Identifier, ban.Reason, ban.Created, ban.Expiration)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to insert into flood_ban")
+	}
+	return ban, nil
+}
+
+func (s *MysqlFloodStore) ListBans(ctx context.Context) ([]*FloodBan, error) {
+	const q = `
+	SELECT * FROM flood_ban
+	WHERE expiration IS NULL OR expiration > ?
+	ORDER BY created DESC
+	`
+	var bans []*FloodBan
+	if err := s.Db.SelectContext(ctx, &bans, q, time.Now()); err != nil {
+		return nil, errors.Wrapf(err, "failed to query flood_ban")
+	}
+	return bans, nil
+}
+
+func (s *MysqlFloodStore) RemoveBans(ctx context.Context, kind FloodBanKind, event string, identifier string) (int64, error) {
+	q := `DELETE FROM flood_ban WHERE kind = ? AND identifier = ?`
+	args := []interface{}{kind, identifier}
+	if event != "" {
+		q += ` AND event = ?`
+		args = append(args, event)
+	}
+	res, err := s.Db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to delete from flood_ban")
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		panic(errors.Wrapf(err, "failed to get rows affected"))
+	}
+	return affected, nil
+}
+
+// isMysqlNoSuchTable returns true if the table does not exist, i.e. the
+// flood_ban table is optional.
+func isMysqlNoSuchTable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1146
+}
+
+// floodBanStore returns the store as a FloodBanStore, for FloodStoreCommand.
+func floodBanStore(store FloodStore) (FloodBanStore, error) {
+	banStore, ok := store.(FloodBanStore)
+	if !ok {
+		return nil, errors.Errorf("%T does not support bans", store)
+	}
+	return banStore, nil
+}
@@ -0,0 +1,12 @@
+CREATE TABLE flood_ban (
+  id BINARY(36) DEFAULT UUID() NOT NULL PRIMARY KEY,
+  kind VARCHAR(8) NOT NULL,
+  event VARCHAR(64) DEFAULT '' NOT NULL,
+  identifier VARCHAR(128) NOT NULL,
+  reason VARCHAR(255) DEFAULT '' NOT NULL,
+  created DATETIME DEFAULT NOW() NOT NULL,
+  expiration DATETIME NULL
+);
+
+CREATE INDEX flood_ban_match ON flood_ban (event, expiration);
+CREATE INDEX flood_ban_identifier ON flood_ban (identifier, event);
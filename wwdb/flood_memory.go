@@ -0,0 +1,226 @@
+package wwdb
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryFloodStore is an in-process sliding window FloodStore & FloodBanStore,
+// for single instance apps & tests.
+type MemoryFloodStore struct {
+	mut  sync.Mutex
+	hits map[floodKey][]memoryFloodHit
+	bans []*FloodBan
+}
+
+type floodKey struct {
+	event      string
+	identifier string
+}
+
+type memoryFloodHit struct {
+	timestamp  time.Time
+	expiration time.Time
+}
+
+func NewMemoryFloodStore() *MemoryFloodStore {
+	return &MemoryFloodStore{hits: map[floodKey][]memoryFloodHit{}}
+}
+
+func (s *MemoryFloodStore) Register(ctx context.Context, event string, identifier string, window time.Duration) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	now := time.Now()
+	key := floodKey{event: event, identifier: identifier}
+	s.hits[key] = append(s.prune(key, now), memoryFloodHit{timestamp: now, expiration: now.Add(window)})
+	return nil
+}
+
+func (s *MemoryFloodStore) Hit(ctx context.Context, event string, identifier string, window time.Duration, threshold int) (bool, time.Duration, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	now := time.Now()
+	key := floodKey{event: event, identifier: identifier}
+	hits := s.prune(key, now)
+	var timestamps []time.Time
+	for _, hit := range hits {
+		if hit.timestamp.After(now.Add(-window)) {
+			timestamps = append(timestamps, hit.timestamp)
+		}
+	}
+	if len(timestamps) >= threshold {
+		return false, floodRetryAfter(timestamps, window, threshold, now), nil
+	}
+	s.hits[key] = append(hits, memoryFloodHit{timestamp: now, expiration: now.Add(window)})
+	return true, 0, nil
+}
+
+func (s *MemoryFloodStore) Count(ctx context.Context, event string, identifier string, window time.Duration) (int, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	since := time.Now().Add(-window)
+	count := 0
+	for _, hit := range s.hits[floodKey{event: event, identifier: identifier}] {
+		if hit.timestamp.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryFloodStore) Clear(ctx context.Context, event string, identifier string) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	delete(s.hits, floodKey{event: event, identifier: identifier})
+	return nil
+}
+
+func (s *MemoryFloodStore) Empty(ctx context.Context, event string) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for key := range s.hits {
+		if key.event == event {
+			delete(s.hits, key)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryFloodStore) Unban(ctx context.Context, identifier string) (int64, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	var deleted int64
+	for key, hits := range s.hits {
+		if key.identifier == identifier {
+			deleted += int64(len(hits))
+			delete(s.hits, key)
+		}
+	}
+	return deleted, nil
+}
+
+func (s *MemoryFloodStore) GC(ctx context.Context) (int64, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	now := time.Now()
+	var deleted int64
+	for key, hits := range s.hits {
+		remaining := s.prune(key, now)
+		deleted += int64(len(hits) - len(remaining))
+	}
+	return deleted, nil
+}
+
+func (s *MemoryFloodStore) Summary(ctx context.Context) ([]*FloodSummaryItem, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	now := time.Now()
+	var res []*FloodSummaryItem
+	for key, hits := range s.hits {
+		item := &FloodSummaryItem{Event: key.event, Identifier: key.identifier}
+		for _, hit := range hits {
+			if !hit.expiration.After(now) {
+				continue
+			}
+			item.Count++
+			if hit.timestamp.After(item.LastHit) {
+				item.LastHit = hit.timestamp
+			}
+		}
+		if item.Count != 0 {
+			res = append(res, item)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].LastHit.Before(res[j].LastHit)
+	})
+	return res, nil
+}
+
+// prune removes the expired hits for the key, the lock must be held.
+func (s *MemoryFloodStore) prune(key floodKey, now time.Time) []memoryFloodHit {
+	hits := s.hits[key]
+	remaining := hits[:0]
+	for _, hit := range hits {
+		if hit.expiration.After(now) {
+			remaining = append(remaining, hit)
+		}
+	}
+	if len(remaining) == 0 {
+		delete(s.hits, key)
+		return nil
+	}
+	s.hits[key] = remaining
+	return remaining
+}
+
+func (s *MemoryFloodStore) AddBan(ctx context.Context, ban *FloodBan) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.bans = append(s.bans, ban)
+	return nil
+}
+
+func (s *MemoryFloodStore) MatchBans(ctx context.Context, event string, identifier string) ([]*FloodBan, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	now := time.Now()
+	var res []*FloodBan
+	for _, ban := range s.bans {
+		if !ban.IsExpired(now) && ban.Matches(event, identifier) {
+			res = append(res, ban)
+		}
+	}
+	return res, nil
+}
+
+func (s *MemoryFloodStore) Escalate(ctx context.Context, event string, identifier string, since time.Time, newBan func(offences int) *FloodBan) (*FloodBan, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	now := time.Now()
+	offences := 0
+	for _, ban := range s.bans {
+		if ban.Kind != FloodBanKindBan || ban.Event != event || ban.Identifier != identifier {
+			continue
+		}
+		if !ban.IsExpired(now) {
+			return ban, nil
+		}
+		if ban.Created.After(since) {
+			offences++
+		}
+	}
+	ban := newBan(offences)
+	s.bans = append(s.bans, ban)
+	return ban, nil
+}
+
+func (s *MemoryFloodStore) ListBans(ctx context.Context) ([]*FloodBan, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	now := time.Now()
+	var res []*FloodBan
+	for i := len(s.bans) - 1; i >= 0; i-- {
+		if !s.bans[i].IsExpired(now) {
+			res = append(res, s.bans[i])
+		}
+	}
+	return res, nil
+}
+
+func (s *MemoryFloodStore) RemoveBans(ctx context.Context, kind FloodBanKind, event string, identifier string) (int64, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	remaining := s.bans[:0]
+	for _, ban := range s.bans {
+		if ban.Kind == kind && ban.Identifier == identifier && (event == "" || ban.Event == event) {
+			continue
+		}
+		remaining = append(remaining, ban)
+	}
+	deleted := int64(len(s.bans) - len(remaining))
+	s.bans = remaining
+	return deleted, nil
+}
@@ -0,0 +1,244 @@
+package wwdb
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/weavingwebs/wwgo"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RespFloodStore is a sliding window FloodStore for RESP servers (i.e.
+// Redis), each event & identifier is a sorted set of hit timestamps that
+// expires with the window. Event names must not contain ':'.
+type RespFloodStore struct {
+	Client *RespClient
+	// Optional, defaults to "flood:".
+	KeyPrefix string
+}
+
+func (s *RespFloodStore) prefix() string {
+	if s.KeyPrefix == "" {
+		return "flood:"
+	}
+	return s.KeyPrefix
+}
+
+func (s *RespFloodStore) key(event string, identifier string) string {
+	return s.prefix() + event + ":" + identifier
+}
+
+func (s *RespFloodStore) Register(ctx context.Context, event string, identifier string, window time.Duration) error {
+	now := time.Now()
+	key := s.key(event, identifier)
+	replies, err := s.Client.Pipeline(ctx, [][]interface{}{
+		{"ZREMRANGEBYSCORE", key, "-inf", now.Add(-window).UnixMilli()},
+		{"ZADD", key, now.UnixMilli(), strconv.FormatInt(now.UnixNano(), 10) + ":" + uuid.NewString()},
+		{"PEXPIRE", key, window.Milliseconds()},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to register flood hit")
+	}
+	for _, reply := range replies {
+		if respErr, ok := reply.(*RespError); ok {
+			return errors.Wrapf(respErr, "failed to register flood hit")
+		}
+	}
+	return nil
+}
+
+// respFloodHitScript trims the set, then adds the hit if allowed, returning
+// {allowed, retryAfterMs}.
+const respFloodHitScript = `
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local threshold = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+if count < threshold then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, window)
+	return {1, 0}
+end
+if threshold <= 0 then
+	return {0, window}
+end
+local oldest = redis.call('ZRANGE', key, count - threshold, count - threshold, 'WITHSCORES')
+return {0, tonumber(oldest[2]) + window - now}
+`
+
+// Hit runs as a Lua script so it is atomic.
+func (s *RespFloodStore) Hit(ctx context.Context, event string, identifier string, window time.Duration, threshold int) (bool, time.Duration, error) {
+	now := time.Now()
+	reply, err := s.Client.Do(ctx, "EVAL", respFloodHitScript, 1, s.key(event, identifier),
+		now.UnixMilli(),
+		window.Milliseconds(),
+		threshold,
+		strconv.FormatInt(now.UnixNano(), 10)+":"+uuid.NewString(),
+	)
+	if err != nil {
+		return false, 0, errors.Wrapf(err, "failed to hit flood")
+	}
+	res, ok := reply.([]interface{})
+	if !ok || len(res) != 2 {
+		return false, 0, errors.Errorf("unexpected flood hit reply %v", reply)
+	}
+	allowed, err := RespInt(res[0])
+	if err != nil {
+		return false, 0, err
+	}
+	retryAfterMs, err := RespInt(res[1])
+	if err != nil {
+		return false, 0, err
+	}
+	return allowed == 1, time.Duration(max(retryAfterMs, 0)) * time.Millisecond, nil
+}
+
+func (s *RespFloodStore) Count(ctx context.Context, event string, identifier string, window time.Duration) (int, error) {
+	since := time.Now().Add(-window).UnixMilli()
+	reply, err := s.Client.Do(ctx, "ZCOUNT", s.key(event, identifier), "("+strconv.FormatInt(since, 10), "+inf")
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to count flood hits")
+	}
+	count, err := RespInt(reply)
+	return int(count), err
+}
+
+func (s *RespFloodStore) Clear(ctx context.Context, event string, identifier string) error {
+	if _, err := s.Client.Do(ctx, "DEL", s.key(event, identifier)); err != nil {
+		return errors.Wrapf(err, "failed to delete flood hits")
+	}
+	return nil
+}
+
+func (s *RespFloodStore) Empty(ctx context.Context, event string) error {
+	keys, err := s.scan(ctx, escapeRespPattern(s.prefix()+event)+":*")
+	if err != nil {
+		return err
+	}
+	for _, chunk := range wwgo.ChunkSlice(keys, 100) {
+		args := []interface{}{"DEL"}
+		for _, key := range chunk {
+			args = append(args, key)
+		}
+		if _, err := s.Client.Do(ctx, args...); err != nil {
+			return errors.Wrapf(err, "failed to delete all flood hits")
+		}
+	}
+	return nil
+}
+
+func (s *RespFloodStore) Unban(ctx context.Context, identifier string) (int64, error) {
+	keys, err := s.scan(ctx, escapeRespPattern(s.prefix())+"*:"+escapeRespPattern(identifier))
+	if err != nil {
+		return 0, err
+	}
+	var deleted int64
+	for _, key := range keys {
+		// The pattern could also match identifiers containing ':'.
+		if _, keyIdentifier := s.parseKey(key); keyIdentifier != identifier {
+			continue
+		}
+		replies, err := s.Client.Pipeline(ctx, [][]interface{}{
+			{"ZCARD", key},
+			{"DEL", key},
+		})
+		if err != nil {
+			return deleted, errors.Wrapf(err, "failed to delete flood hits")
+		}
+		count, err := RespInt(replies[0])
+		if err != nil {
+			return deleted, err
+		}
+		deleted += count
+	}
+	return deleted, nil
+}
+
+// GC is a no-op, the keys expire with the window.
+func (s *RespFloodStore) GC(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// Summary counts every hit in each set, which may include a few expired hits
+// as they are only removed on Register.
+func (s *RespFloodStore) Summary(ctx context.Context) ([]*FloodSummaryItem, error) {
+	keys, err := s.scan(ctx, escapeRespPattern(s.prefix())+"*")
+	if err != nil {
+		return nil, err
+	}
+	var res []*FloodSummaryItem
+	for _, key := range keys {
+		replies, err := s.Client.Pipeline(ctx, [][]interface{}{
+			{"ZCARD", key},
+			{"ZRANGE", key, -1, -1, "WITHSCORES"},
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get flood summary")
+		}
+		count, err := RespInt(replies[0])
+		if err != nil {
+			return nil, err
+		}
+		last, err := RespStrings(replies[1])
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 || len(last) != 2 {
+			continue
+		}
+		lastMs, err := strconv.ParseFloat(last[1], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid flood score")
+		}
+		event, identifier := s.parseKey(key)
+		res = append(res, &FloodSummaryItem{
+			Event:      event,
+			Identifier: identifier,
+			Count:      int(count),
+			LastHit:    time.UnixMilli(int64(lastMs)),
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].LastHit.Before(res[j].LastHit)
+	})
+	return res, nil
+}
+
+func (s *RespFloodStore) parseKey(key string) (string, string) {
+	event, identifier, _ := strings.Cut(strings.TrimPrefix(key, s.prefix()), ":")
+	return event, identifier
+}
+
+func (s *RespFloodStore) scan(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	cursor := "0"
+	for {
+		reply, err := s.Client.Do(ctx, "SCAN", cursor, "MATCH", pattern, "COUNT", 1000)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to scan flood keys")
+		}
+		arr, ok := reply.([]interface{})
+		if !ok || len(arr) != 2 {
+			return nil, errors.Errorf("unexpected SCAN reply %v", reply)
+		}
+		cursor, _ = arr[0].(string)
+		batch, err := RespStrings(arr[1])
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, batch...)
+		if cursor == "0" || cursor == "" {
+			return keys, nil
+		}
+	}
+}
+
+func escapeRespPattern(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+	return r.Replace(s)
+}
@@ -0,0 +1,78 @@
+package wwdb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFloodBanExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryFloodStore()
+	flood := &Flood{
+		Store:        store,
+		Bans:         store,
+		Name:         "login",
+		Window:       time.Minute,
+		Threshold:    2,
+		BanDurations: []time.Duration{50 * time.Millisecond, time.Hour},
+	}
+
+	for i := 0; i < 2; i++ {
+		if allowed, _, err := flood.Hit(ctx, "1.2.3.4"); err != nil || !allowed {
+			t.Fatalf("expected hit %d to be allowed, got %v %v", i, allowed, err)
+		}
+	}
+	allowed, retryAfter, err := flood.Hit(ctx, "1.2.3.4")
+	if err != nil || allowed {
+		t.Fatalf("expected to be banned, got %v %v", allowed, err)
+	}
+	if retryAfter > 50*time.Millisecond {
+		t.Fatalf("expected the first ban duration, got %s", retryAfter)
+	}
+
+	// The hits that caused the ban are still within the window.
+	time.Sleep(60 * time.Millisecond)
+	if allowed, _, err := flood.Hit(ctx, "1.2.3.4"); err != nil || !allowed {
+		t.Fatalf("expected a hit after the ban to be allowed, got %v %v", allowed, err)
+	}
+	bans, err := store.ListBans(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bans) != 0 {
+		t.Fatalf("expected no active bans, got %d", len(bans))
+	}
+}
+
+func TestFloodBanEscalates(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryFloodStore()
+	flood := &Flood{
+		Store:        store,
+		Bans:         store,
+		Name:         "login",
+		Window:       time.Minute,
+		Threshold:    1,
+		BanDurations: []time.Duration{10 * time.Millisecond, time.Hour},
+	}
+
+	// Offend, wait for the ban to expire & offend again.
+	for i := 0; i < 2; i++ {
+		if allowed, _, _ := flood.Hit(ctx, "1.2.3.4"); !allowed {
+			t.Fatalf("expected the first hit of round %d to be allowed", i)
+		}
+		if allowed, _, _ := flood.Hit(ctx, "1.2.3.4"); allowed {
+			t.Fatalf("expected the second hit of round %d to be banned", i)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	bans, err := store.ListBans(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bans) != 1 || !bans[0].Expiration.Valid || time.Until(bans[0].Expiration.V) < 59*time.Minute {
+		t.Fatalf("expected the second offence to get the longer ban, got %v", bans)
+	}
+}
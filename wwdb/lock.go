@@ -19,40 +19,31 @@ var lockMutexes = make(map[string]*lock)
 var lockMutexesLock = &sync.Mutex{}
 
 func Lock(ctx context.Context, db *sqlx.DB, name string) error {
-	lockMutexesLock.Lock()
-	lockMutex, ok := lockMutexes[name]
-	if !ok {
-		lockMutex = &lock{
-			mut: trylock.New(),
-		}
-		lockMutexes[name] = lockMutex
-	}
-	lockMutexesLock.Unlock()
+	lockMutex := getLockMutex(name)
 	if !lockMutex.mut.TryLock(ctx) {
 		return errors.Errorf("failed to aquire lock %s", name)
 	}
 
-	// Obtain a specific DB connection to run the lock statements on.
-	var err error
-	lockMutex.conn, err = db.Connx(ctx)
+	success, err := lockMutex.dbLock(ctx, db, name, 30)
 	if err != nil {
-		return errors.Errorf("failed to obtain a DB connection")
-	}
-
-	// Lock via DB.
-	row := lockMutex.conn.QueryRowxContext(ctx, `SELECT GET_LOCK(?, 30)`, name)
-	var success bool
-	if err := row.Scan(&success); err != nil {
-		_ = lockMutex.conn.Close()
-		return errors.Wrapf(err, "failed to lock %s", name)
+		return err
 	}
 	if !success {
-		_ = lockMutex.conn.Close()
 		return errors.Errorf("could not aquire lock, timed out")
 	}
 	return nil
 }
 
+// TryLock attempts to obtain the lock without waiting. It returns false if the
+// lock is already held, either by this instance or another connection.
+func TryLock(ctx context.Context, db *sqlx.DB, name string) (bool, error) {
+	lockMutex := getLockMutex(name)
+	if !lockMutex.mut.TryLockTimeout(0) {
+		return false, nil
+	}
+	return lockMutex.dbLock(ctx, db, name, 0)
+}
+
 func Unlock(ctx context.Context, name string) error {
 	lockMutexesLock.Lock()
 	lockMutex, ok := lockMutexes[name]
@@ -69,3 +60,43 @@ func Unlock(ctx context.Context, name string) error {
 
 	return nil
 }
+
+func getLockMutex(name string) *lock {
+	lockMutexesLock.Lock()
+	defer lockMutexesLock.Unlock()
+	lockMutex, ok := lockMutexes[name]
+	if !ok {
+		lockMutex = &lock{
+			mut: trylock.New(),
+		}
+		lockMutexes[name] = lockMutex
+	}
+	return lockMutex
+}
+
+// dbLock must be called whilst holding l.mut, it will be released if the DB
+// lock is not obtained.
+func (l *lock) dbLock(ctx context.Context, db *sqlx.DB, name string, timeoutSeconds int) (bool, error) {
+	// Obtain a specific DB connection to run the lock statements on.
+	var err error
+	l.conn, err = db.Connx(ctx)
+	if err != nil {
+		l.mut.Unlock()
+		return false, errors.Errorf("failed to obtain a DB connection")
+	}
+
+	// Lock via DB.
+	row := l.conn.QueryRowxContext(ctx, `SELECT GET_LOCK(?, ?)`, name, timeoutSeconds)
+	var success bool
+	if err := row.Scan(&success); err != nil {
+		_ = l.conn.Close()
+		l.mut.Unlock()
+		return false, errors.Wrapf(err, "failed to lock %s", name)
+	}
+	if !success {
+		_ = l.conn.Close()
+		l.mut.Unlock()
+		return false, nil
+	}
+	return true, nil
+}
@@ -4,23 +4,65 @@ import (
 	"fmt"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/source"
 	"github.com/golang-migrate/migrate/v4/source/go_bindata"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jmoiron/sqlx"
+	"github.com/olekukonko/tablewriter"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 	"github.com/weavingwebs/wwgo"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Deprecated: go-bindata is unmaintained, use embed with MysqlDbMigrateFs.
 func MysqlDbMigrate(db *sqlx.DB, migrations *bindata.AssetSource) (*migrate.Migrate, error) {
-	dbDriver, err := mysql.WithInstance(db.DB, &mysql.Config{})
+	dataDriver, err := bindata.WithInstance(migrations)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to init migrate db driver")
+		return nil, errors.Wrapf(err, "failed to init migrate data driver")
 	}
+	m, err := MysqlDbMigrateSource(db, dataDriver)
+	if err != nil {
+		return nil, err
+	}
+	return m.Migrate, nil
+}
 
-	dataDriver, err := bindata.WithInstance(migrations)
+// Migrator is a *migrate.Migrate with its migrations, as migrate does not
+// expose them.
+type Migrator struct {
+	Migrate *migrate.Migrate
+	// Nil if the migrations are unknown.
+	Source source.Driver
+}
+
+// MysqlDbMigrateFs reads the migrations from the directory in fsys, i.e.
+//
+//	//go:embed migrations/*.sql
+//	var migrations embed.FS
+//
+//	wwdb.MysqlDbMigrateFs(db, migrations, "migrations")
+func MysqlDbMigrateFs(db *sqlx.DB, fsys fs.FS, dir string) (*Migrator, error) {
+	dataDriver, err := iofs.New(fsys, dir)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to init migrate data driver")
 	}
+	return MysqlDbMigrateSource(db, dataDriver)
+}
+
+// MysqlDbMigrateSource reads the migrations from any migrate source driver.
+func MysqlDbMigrateSource(db *sqlx.DB, dataDriver source.Driver) (*Migrator, error) {
+	dbDriver, err := mysql.WithInstance(db.DB, &mysql.Config{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to init migrate db driver")
+	}
 
 	migrator, err := migrate.NewWithInstance(
 		"migrations",
@@ -32,17 +74,44 @@ func MysqlDbMigrate(db *sqlx.DB, migrations *bindata.AssetSource) (*migrate.Migr
 		return nil, errors.Wrapf(err, "failed to init migrate")
 	}
 
-	return migrator, nil
+	return &Migrator{Migrate: migrator, Source: dataDriver}, nil
+}
+
+type MigrateCommandOpt struct {
+	// Optional, the directory create writes to, defaults to "migrations".
+	CreateDir string
 }
 
+// MigrateCommand does not know the migrations, so status & up --dry-run can't
+// list the pending ones, see MigratorCommand.
 func MigrateCommand(migrator func() *migrate.Migrate) *cli.Command {
+	return MigratorCommand(func() *Migrator {
+		return &Migrator{Migrate: migrator()}
+	}, MigrateCommandOpt{})
+}
+
+// MigratorCommand is MigrateCommand for a Migrator, with options.
+func MigratorCommand(migrator func() *Migrator, opt MigrateCommandOpt) *cli.Command {
+	if opt.CreateDir == "" {
+		opt.CreateDir = "migrations"
+	}
+
 	return &cli.Command{
 		Name: "migrate",
 		Subcommands: []*cli.Command{
 			{
 				Name: "up",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Print the SQL of the pending migrations without applying them",
+					},
+				},
 				Action: func(ctx *cli.Context) error {
-					if err := migrator().Up(); err != nil {
+					if ctx.Bool("dry-run") {
+						return migrateDryRun(migrator())
+					}
+					if err := migrator().Migrate.Up(); err != nil {
 						if err != migrate.ErrNoChange {
 							return err
 						}
@@ -54,7 +123,7 @@ func MigrateCommand(migrator func() *migrate.Migrate) *cli.Command {
 			},
 			{
 				Name: "down",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.BoolFlag{
 						Name:    "yes",
 						Aliases: []string{"y"},
@@ -63,19 +132,156 @@ func MigrateCommand(migrator func() *migrate.Migrate) *cli.Command {
 						Name:  "steps",
 						Value: 1,
 					},
+				}, wwgo.PromptFlags()...),
+				Action: func(ctx *cli.Context) error {
+					steps := ctx.Int("steps")
+					if steps < 1 {
+						return errors.Errorf("steps must be at least 1")
+					}
+					if !ctx.Bool("yes") {
+						prompter, err := wwgo.PrompterFromCli(ctx)
+						if err != nil {
+							return err
+						}
+						msg := fmt.Sprintf("Are you sure you want to apply %d down %s?", steps, wwgo.Plural(steps, "migration", "migrations"))
+						yes, err := prompter.Confirm(wwgo.Question{Name: "down", Message: msg})
+						if err != nil {
+							return err
+						}
+						if !yes {
+							fmt.Println("cancelled")
+							return nil
+						}
+					}
+					if err := migrator().Migrate.Steps(-steps); err != nil {
+						return err
+					}
+					fmt.Println("👍️")
+					return nil
 				},
+			},
+			{
+				Name:      "goto",
+				Usage:     "Migrate up or down to a version, use --answer goto=y (or PROMPT_ANSWER_GOTO=y) to migrate down non-interactively",
+				ArgsUsage: "<version>",
+				Flags: append([]cli.Flag{
+					&cli.BoolFlag{
+						Name:    "yes",
+						Aliases: []string{"y"},
+					},
+				}, wwgo.PromptFlags()...),
 				Action: func(ctx *cli.Context) error {
-					if !ctx.Bool("yes") && !wwgo.CliConfirm("Are you sure you want to apply 1 down migration?") {
-						fmt.Println("cancelled")
-						return nil
+					target, err := strconv.ParseUint(ctx.Args().First(), 10, 64)
+					if err != nil {
+						return errors.Errorf("Argument required: version")
 					}
-					if err := migrator().Steps(-ctx.Int("steps")); err != nil {
+					m := migrator()
+					current, _, err := m.Migrate.Version()
+					if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
 						return err
 					}
+					if uint(target) < current && !ctx.Bool("yes") {
+						prompter, err := wwgo.PrompterFromCli(ctx)
+						if err != nil {
+							return err
+						}
+						msg := fmt.Sprintf("Are you sure you want to migrate down from %d to %d?", current, target)
+						yes, err := prompter.Confirm(wwgo.Question{Name: "goto", Message: msg})
+						if err != nil {
+							return err
+						}
+						if !yes {
+							fmt.Println("cancelled")
+							return nil
+						}
+					}
+					if err := m.Migrate.Migrate(uint(target)); err != nil {
+						if err != migrate.ErrNoChange {
+							return err
+						}
+						fmt.Println(err)
+					}
 					fmt.Println("👍️")
 					return nil
 				},
 			},
+			{
+				Name:  "status",
+				Usage: "Show the current version & the pending migrations",
+				Action: func(ctx *cli.Context) error {
+					m := migrator()
+					version, dirty, err := m.Migrate.Version()
+					hasVersion := true
+					if errors.Is(err, migrate.ErrNilVersion) {
+						hasVersion = false
+						fmt.Println("No migrations have been applied")
+					} else if err != nil {
+						return err
+					} else if dirty {
+						fmt.Printf("Version: %d (dirty, fix the database & use force)\n", version)
+					} else {
+						fmt.Printf("Version: %d\n", version)
+					}
+
+					if m.Source == nil {
+						fmt.Println("The pending migrations are unknown, use MigratorCommand to list them")
+						return nil
+					}
+					pending, err := pendingMigrations(m.Source, version, hasVersion)
+					if err != nil {
+						return err
+					}
+					if len(pending) == 0 {
+						fmt.Println("There are no pending migrations")
+						return nil
+					}
+					table := tablewriter.NewWriter(os.Stdout)
+					table.SetHeader([]string{
+						"Pending",
+						"Name",
+					})
+					for _, p := range pending {
+						table.Append([]string{
+							strconv.FormatUint(uint64(p.Version), 10),
+							p.Identifier,
+						})
+					}
+					table.Render()
+					return nil
+				},
+			},
+			{
+				Name:      "create",
+				Usage:     "Create a timestamped up & down migration",
+				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "dir",
+						Value: opt.CreateDir,
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					name := strings.Trim(migrationNameRe.ReplaceAllString(strings.ToLower(ctx.Args().First()), "_"), "_")
+					if name == "" {
+						return errors.Errorf("Argument required: name")
+					}
+					if err := os.MkdirAll(ctx.String("dir"), 0755); err != nil {
+						return errors.Wrapf(err, "failed to create %s", ctx.String("dir"))
+					}
+					prefix := filepath.Join(ctx.String("dir"), time.Now().UTC().Format("20060102150405")+"_"+name)
+					for _, path := range []string{prefix + ".up.sql", prefix + ".down.sql"} {
+						f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+						if err != nil {
+							return errors.Wrapf(err, "failed to create %s", path)
+						}
+						if err := f.Close(); err != nil {
+							return errors.Wrapf(err, "failed to create %s", path)
+						}
+						fmt.Println(path)
+					}
+					return nil
+				},
+			},
 			{
 				Name: "force",
 				Flags: []cli.Flag{
@@ -85,7 +291,7 @@ func MigrateCommand(migrator func() *migrate.Migrate) *cli.Command {
 					},
 				},
 				Action: func(ctx *cli.Context) error {
-					if err := migrator().Force(ctx.Int("version")); err != nil {
+					if err := migrator().Migrate.Force(ctx.Int("version")); err != nil {
 						return err
 					}
 					fmt.Println("👍️")
@@ -93,19 +299,35 @@ func MigrateCommand(migrator func() *migrate.Migrate) *cli.Command {
 				},
 			},
 			{
-				Name: "drop",
-				Flags: []cli.Flag{
+				Name:  "drop",
+				Usage: "Drop everything in the database, use --answer drop=y (or PROMPT_ANSWER_DROP=y) to run non-interactively",
+				Flags: append([]cli.Flag{
 					&cli.BoolFlag{
 						Name:    "yes",
 						Aliases: []string{"y"},
 					},
-				},
+				}, wwgo.PromptFlags()...),
 				Action: func(ctx *cli.Context) error {
-					if !ctx.Bool("yes") && !(wwgo.CliConfirm("Are you sure you want to destroy the whole database?") && wwgo.CliConfirm("Seriously, you are really sure you want to destroy the whole database?")) {
-						fmt.Println("cancelled")
-						return nil
+					if !ctx.Bool("yes") {
+						prompter, err := wwgo.PrompterFromCli(ctx)
+						if err != nil {
+							return err
+						}
+						for _, msg := range []string{
+							"Are you sure you want to destroy the whole database?",
+							"Seriously, you are really sure you want to destroy the whole database?",
+						} {
+							yes, err := prompter.Confirm(wwgo.Question{Name: "drop", Message: msg})
+							if err != nil {
+								return err
+							}
+							if !yes {
+								fmt.Println("cancelled")
+								return nil
+							}
+						}
 					}
-					if err := migrator().Drop(); err != nil {
+					if err := migrator().Migrate.Drop(); err != nil {
 						return err
 					}
 					fmt.Println("👍️")
@@ -115,3 +337,81 @@ func MigrateCommand(migrator func() *migrate.Migrate) *cli.Command {
 		},
 	}
 }
+
+var migrationNameRe = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+type pendingMigration struct {
+	Version    uint
+	Identifier string
+	// Empty if the version only has a down migration.
+	Sql string
+}
+
+// pendingMigrations lists the migrations in src after the version.
+func pendingMigrations(src source.Driver, version uint, hasVersion bool) ([]pendingMigration, error) {
+	var res []pendingMigration
+	next := src.First
+	if hasVersion {
+		next = func() (uint, error) { return src.Next(version) }
+	}
+	for {
+		v, err := next()
+		if errors.Is(err, fs.ErrNotExist) {
+			return res, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read migrations")
+		}
+		sql, identifier, err := readMigration(src, v)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, pendingMigration{Version: v, Identifier: identifier, Sql: sql})
+		next = func() (uint, error) { return src.Next(v) }
+	}
+}
+
+// readMigration returns the up SQL & identifier.
+func readMigration(src source.Driver, version uint) (string, string, error) {
+	r, identifier, err := src.ReadUp(version)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to read migration %d", version)
+	}
+	defer func() { _ = r.Close() }()
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to read migration %d", version)
+	}
+	return string(body), identifier, nil
+}
+
+func migrateDryRun(m *Migrator) error {
+	if m.Source == nil {
+		return errors.Errorf("the pending migrations are unknown, use MigratorCommand to list them")
+	}
+	version, dirty, err := m.Migrate.Version()
+	hasVersion := !errors.Is(err, migrate.ErrNilVersion)
+	if err != nil && hasVersion {
+		return err
+	}
+	if dirty {
+		fmt.Printf("-- WARNING: version %d is dirty, up will fail until it is forced\n", version)
+	}
+
+	pending, err := pendingMigrations(m.Source, version, hasVersion)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Println(migrate.ErrNoChange)
+		return nil
+	}
+
+	for _, p := range pending {
+		fmt.Printf("-- %d_%s\n%s\n", p.Version, p.Identifier, p.Sql)
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package wwdb
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RespClientOpt configures a RespClient.
+type RespClientOpt struct {
+	// i.e. "localhost:6379".
+	Addr string
+	// Optional, sent with AUTH.
+	Username string
+	// Optional, sent with AUTH.
+	Password string
+	// Optional, sent with SELECT.
+	Db int
+	// Optional, defaults to 5 seconds.
+	DialTimeout time.Duration
+	// Optional, deadline for each command without a context deadline, defaults
+	// to 5 seconds.
+	Timeout time.Duration
+	// Optional, defaults to 10.
+	MaxIdleConns int
+	// Optional, defaults to dialing Addr with TCP, i.e. to use TLS.
+	Dial func(ctx context.Context) (net.Conn, error)
+}
+
+// RespClient is a minimal client for servers using the Redis serialization
+// protocol (RESP2), i.e. Redis, Valkey, KeyDB or DragonflyDB.
+type RespClient struct {
+	opt  RespClientOpt
+	mut  sync.Mutex
+	idle []*respConn
+}
+
+// RespError is an error reply from the server.
+type RespError struct {
+	Message string
+}
+
+func (e *RespError) Error() string {
+	return e.Message
+}
+
+type respConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+func NewRespClient(opt RespClientOpt) *RespClient {
+	if opt.DialTimeout == 0 {
+		opt.DialTimeout = 5 * time.Second
+	}
+	if opt.Timeout == 0 {
+		opt.Timeout = 5 * time.Second
+	}
+	if opt.MaxIdleConns == 0 {
+		opt.MaxIdleConns = 10
+	}
+	if opt.Dial == nil {
+		opt.Dial = func(ctx context.Context) (net.Conn, error) {
+			d := net.Dialer{Timeout: opt.DialTimeout}
+			return d.DialContext(ctx, "tcp", opt.Addr)
+		}
+	}
+	return &RespClient{opt: opt}
+}
+
+// Do sends a command & returns the reply, which is nil, string, int64,
+// *RespError (as the error) or []interface{}.
+func (c *RespClient) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	replies, err := c.Pipeline(ctx, [][]interface{}{args})
+	if err != nil {
+		return nil, err
+	}
+	if respErr, ok := replies[0].(*RespError); ok {
+		return nil, respErr
+	}
+	return replies[0], nil
+}
+
+// Pipeline sends the commands together & returns every reply, error replies
+// are returned as *RespError in the replies.
+func (c *RespClient) Pipeline(ctx context.Context, cmds [][]interface{}) ([]interface{}, error) {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := c.pipeline(ctx, conn, cmds)
+	if err != nil {
+		// The connection state is unknown.
+		_ = conn.conn.Close()
+		return nil, err
+	}
+	c.put(conn)
+	return replies, nil
+}
+
+func (c *RespClient) pipeline(ctx context.Context, conn *respConn, cmds [][]interface{}) ([]interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(c.opt.Timeout)
+	}
+	if err := conn.conn.SetDeadline(deadline); err != nil {
+		return nil, errors.Wrapf(err, "failed to set deadline")
+	}
+
+	// Close the connection to interrupt the I/O if the context is cancelled.
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.conn.Close()
+	})
+	replies, err := conn.roundTrip(cmds)
+	if !stop() {
+		return nil, ctx.Err()
+	}
+	return replies, err
+}
+
+func (conn *respConn) roundTrip(cmds [][]interface{}) ([]interface{}, error) {
+	for _, cmd := range cmds {
+		if err := writeRespCommand(conn.w, cmd); err != nil {
+			return nil, err
+		}
+	}
+	if err := conn.w.Flush(); err != nil {
+		return nil, errors.Wrapf(err, "failed to send command")
+	}
+
+	replies := make([]interface{}, len(cmds))
+	for i := range cmds {
+		reply, err := readRespReply(conn.r)
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = reply
+	}
+	return replies, nil
+}
+
+// Close closes the idle connections.
+func (c *RespClient) Close() error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	for _, conn := range c.idle {
+		_ = conn.conn.Close()
+	}
+	c.idle = nil
+	return nil
+}
+
+func (c *RespClient) get(ctx context.Context) (*respConn, error) {
+	c.mut.Lock()
+	if len(c.idle) != 0 {
+		conn := c.idle[len(c.idle)-1]
+		c.idle = c.idle[:len(c.idle)-1]
+		c.mut.Unlock()
+		return conn, nil
+	}
+	c.mut.Unlock()
+
+	netConn, err := c.opt.Dial(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to %s", c.opt.Addr)
+	}
+	conn := &respConn{
+		conn: netConn,
+		r:    bufio.NewReader(netConn),
+		w:    bufio.NewWriter(netConn),
+	}
+
+	// Authenticate & select the db.
+	var setup [][]interface{}
+	if c.opt.Password != "" {
+		if c.opt.Username != "" {
+			setup = append(setup, []interface{}{"AUTH", c.opt.Username, c.opt.Password})
+		} else {
+			setup = append(setup, []interface{}{"AUTH", c.opt.Password})
+		}
+	}
+	if c.opt.Db != 0 {
+		setup = append(setup, []interface{}{"SELECT", c.opt.Db})
+	}
+	if len(setup) != 0 {
+		replies, err := c.pipeline(ctx, conn, setup)
+		if err == nil {
+			for _, reply := range replies {
+				if respErr, ok := reply.(*RespError); ok {
+					err = respErr
+				}
+			}
+		}
+		if err != nil {
+			_ = netConn.Close()
+			return nil, errors.Wrapf(err, "failed to set up connection to %s", c.opt.Addr)
+		}
+	}
+	return conn, nil
+}
+
+func (c *RespClient) put(conn *respConn) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if len(c.idle) >= c.opt.MaxIdleConns {
+		_ = conn.conn.Close()
+		return
+	}
+	c.idle = append(c.idle, conn)
+}
+
+func writeRespCommand(w *bufio.Writer, args []interface{}) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return errors.Wrapf(err, "failed to write command")
+	}
+	for _, arg := range args {
+		var s string
+		switch v := arg.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		case int:
+			s = strconv.Itoa(v)
+		case int64:
+			s = strconv.FormatInt(v, 10)
+		case float64:
+			s = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return errors.Errorf("unsupported argument type %T", arg)
+		}
+		if _, err := fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s); err != nil {
+			return errors.Wrapf(err, "failed to write command")
+		}
+	}
+	return nil
+}
+
+func readRespReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read reply")
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.Errorf("invalid reply '%s'", line)
+	}
+	prefix, body := line[0], line[1:len(line)-2]
+
+	switch prefix {
+	case '+':
+		return body, nil
+	case '-':
+		return &RespError{Message: body}, nil
+	case ':':
+		i, err := strconv.ParseInt(body, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid integer reply")
+		}
+		return i, nil
+	case '$':
+		length, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid bulk string length")
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, length+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, errors.Wrapf(err, "failed to read bulk string")
+		}
+		return string(buf[:length]), nil
+	case '*':
+		length, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid array length")
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		res := make([]interface{}, length)
+		for i := range res {
+			if res[i], err = readRespReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+	return nil, errors.Errorf("unknown reply type '%c'", prefix)
+}
+
+// RespInt converts an integer or numeric string reply.
+func RespInt(reply interface{}) (int64, error) {
+	switch v := reply.(type) {
+	case int64:
+		return v, nil
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "invalid integer reply")
+		}
+		return i, nil
+	case nil:
+		return 0, nil
+	}
+	return 0, errors.Errorf("unexpected reply type %T", reply)
+}
+
+// RespStrings converts an array reply.
+func RespStrings(reply interface{}) ([]string, error) {
+	arr, ok := reply.([]interface{})
+	if !ok && reply != nil {
+		return nil, errors.Errorf("unexpected reply type %T", reply)
+	}
+	res := make([]string, len(arr))
+	for i, v := range arr {
+		s, ok := v.(string)
+		if !ok && v != nil {
+			return nil, errors.Errorf("unexpected array item type %T", v)
+		}
+		res[i] = s
+	}
+	return res, nil
+}
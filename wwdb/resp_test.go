@@ -0,0 +1,216 @@
+package wwdb
+
+import (
+	"bufio"
+	"context"
+	"github.com/pkg/errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRespServer replies to each command with the raw RESP from handler, an
+// empty reply is never sent.
+type fakeRespServer struct {
+	addr  string
+	mut   sync.Mutex
+	cmds  []string
+	dials int
+}
+
+func newFakeRespServer(t *testing.T, handler func(cmd []string) string) *fakeRespServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+
+	s := &fakeRespServer{addr: l.Addr().String()}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.mut.Lock()
+			s.dials++
+			s.mut.Unlock()
+			go s.serve(conn, handler)
+		}
+	}()
+	return s
+}
+
+func (s *fakeRespServer) serve(conn net.Conn, handler func(cmd []string) string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		// Commands are arrays of bulk strings, so they parse as replies.
+		reply, err := readRespReply(r)
+		if err != nil {
+			return
+		}
+		cmd, err := RespStrings(reply)
+		if err != nil {
+			return
+		}
+		s.mut.Lock()
+		s.cmds = append(s.cmds, strings.Join(cmd, " "))
+		s.mut.Unlock()
+		if res := handler(cmd); res != "" {
+			if _, err := conn.Write([]byte(res)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *fakeRespServer) commands() []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]string(nil), s.cmds...)
+}
+
+func (s *fakeRespServer) dialCount() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.dials
+}
+
+func fakeRespHandler(cmd []string) string {
+	switch strings.ToUpper(cmd[0]) {
+	case "AUTH", "SELECT", "SET":
+		return "+OK\r\n"
+	case "GET":
+		if cmd[1] == "missing" {
+			return "$-1\r\n"
+		}
+		return "$5\r\nvalue\r\n"
+	case "INCR":
+		return ":2\r\n"
+	case "KEYS":
+		return "*2\r\n$1\r\na\r\n$1\r\nb\r\n"
+	case "BLPOP":
+		return ""
+	}
+	return "-ERR unknown command '" + cmd[0] + "'\r\n"
+}
+
+func TestRespClientDo(t *testing.T) {
+	s := newFakeRespServer(t, fakeRespHandler)
+	c := NewRespClient(RespClientOpt{Addr: s.addr})
+	defer c.Close()
+	ctx := context.Background()
+
+	if res, err := c.Do(ctx, "SET", "key", "value"); err != nil || res != "OK" {
+		t.Fatalf("expected OK, got %v %v", res, err)
+	}
+	if res, err := c.Do(ctx, "GET", "key"); err != nil || res != "value" {
+		t.Fatalf("expected value, got %v %v", res, err)
+	}
+	if res, err := c.Do(ctx, "GET", "missing"); err != nil || res != nil {
+		t.Fatalf("expected nil, got %v %v", res, err)
+	}
+	res, err := c.Do(ctx, "INCR", "n")
+	if i, _ := RespInt(res); err != nil || i != 2 {
+		t.Fatalf("expected 2, got %v %v", res, err)
+	}
+	res, err = c.Do(ctx, "KEYS", "*")
+	if keys, _ := RespStrings(res); err != nil || strings.Join(keys, ",") != "a,b" {
+		t.Fatalf("expected a,b, got %v %v", res, err)
+	}
+	if s.dialCount() != 1 {
+		t.Fatalf("expected the connection to be reused, got %d dials", s.dialCount())
+	}
+}
+
+func TestRespClientErrorReply(t *testing.T) {
+	s := newFakeRespServer(t, fakeRespHandler)
+	c := NewRespClient(RespClientOpt{Addr: s.addr})
+	defer c.Close()
+	ctx := context.Background()
+
+	_, err := c.Do(ctx, "NOPE")
+	var respErr *RespError
+	if !errors.As(err, &respErr) || respErr.Message != "ERR unknown command 'NOPE'" {
+		t.Fatalf("expected a RespError, got %v", err)
+	}
+
+	// Error replies don't fail the rest of the pipeline.
+	replies, err := c.Pipeline(ctx, [][]interface{}{{"NOPE"}, {"SET", "key", 1}, {"GET", "key"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := replies[0].(*RespError); !ok || replies[1] != "OK" || replies[2] != "value" {
+		t.Fatalf("unexpected replies %v", replies)
+	}
+	if s.dialCount() != 1 {
+		t.Fatalf("expected the connection to be reused after an error reply, got %d dials", s.dialCount())
+	}
+}
+
+func TestRespClientSetup(t *testing.T) {
+	s := newFakeRespServer(t, fakeRespHandler)
+	c := NewRespClient(RespClientOpt{Addr: s.addr, Username: "user", Password: "pass", Db: 2})
+	defer c.Close()
+
+	if _, err := c.Do(context.Background(), "GET", "key"); err != nil {
+		t.Fatal(err)
+	}
+	cmds := s.commands()
+	if strings.Join(cmds, "|") != "AUTH user pass|SELECT 2|GET key" {
+		t.Fatalf("unexpected commands %v", cmds)
+	}
+}
+
+func TestRespClientSetupError(t *testing.T) {
+	s := newFakeRespServer(t, func(cmd []string) string {
+		if cmd[0] == "AUTH" {
+			return "-WRONGPASS invalid password\r\n"
+		}
+		return fakeRespHandler(cmd)
+	})
+	c := NewRespClient(RespClientOpt{Addr: s.addr, Password: "wrong"})
+	defer c.Close()
+
+	_, err := c.Do(context.Background(), "GET", "key")
+	if err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Fatalf("expected an auth error, got %v", err)
+	}
+	for _, cmd := range s.commands() {
+		if strings.HasPrefix(cmd, "GET") {
+			t.Fatal("expected the command not to be sent after a failed AUTH")
+		}
+	}
+}
+
+func TestRespClientCancel(t *testing.T) {
+	s := newFakeRespServer(t, fakeRespHandler)
+	c := NewRespClient(RespClientOpt{Addr: s.addr, Timeout: time.Minute})
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	_, err := c.Do(ctx, "BLPOP", "queue", 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("expected the command to be interrupted by the cancel")
+	}
+
+	// The interrupted connection is not reused.
+	if _, err := c.Do(context.Background(), "GET", "key"); err != nil {
+		t.Fatal(err)
+	}
+	if s.dialCount() != 2 {
+		t.Fatalf("expected a new connection, got %d dials", s.dialCount())
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/shopspring/decimal"
+	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 	"github.com/weavingwebs/wwgo"
 	"github.com/weavingwebs/wwgo/wwgraphql/scalars"
@@ -81,6 +82,9 @@ func DefaultErrorPresenter(log zerolog.Logger) func(ctx context.Context, e error
 			// Use the message from the client error directly, it may have been
 			// wrapped by another error that is not client safe.
 			errResp.Message = clientErr.Error()
+			if fields := clientErr.Fields(); len(fields) != 0 {
+				errResp.Extensions["fields"] = fields
+			}
 
 			// If it wrapped an error, log it as well.
 			if wrappedErr := clientErr.Unwrap(); wrappedErr != nil {
@@ -140,30 +144,38 @@ func ValidateStringDirective(ctx context.Context, obj interface{}, next graphql.
 
 	// Validate.
 	if rules.MinLength != nil && len(str) < *rules.MinLength {
-		return nil, wwgo.NewClientError(
+		return validationFailed(
+			ctx,
+			next,
 			"VALIDATE_STRING_MIN_LENGTH_EXCEPTION",
 			prefixWithLabel(fmt.Sprintf("Cannot be less than %d %s", *rules.MinLength, wwgo.Plural(*rules.MinLength, "character", "characters")), rules.Label),
-			nil,
+			map[string]interface{}{"minLength": *rules.MinLength},
 		)
 	}
 	if rules.MaxLength != nil && len(str) > *rules.MaxLength {
-		return nil, wwgo.NewClientError(
+		return validationFailed(
+			ctx,
+			next,
 			"VALIDATE_STRING_MAX_LENGTH_EXCEPTION",
 			prefixWithLabel(fmt.Sprintf("Cannot be more than %d %s", *rules.MaxLength, wwgo.Plural(*rules.MaxLength, "character", "characters")), rules.Label),
-			nil,
+			map[string]interface{}{"maxLength": *rules.MaxLength},
 		)
 	}
 	if str != "" && rules.Pattern != nil {
 		switch strings.ToUpper(*rules.Pattern) {
 		case "EMAIL":
 			if !cognitoEmailRegexp.MatchString(str) {
-				return nil, wwgo.NewClientError(
+				return validationFailed(
+					ctx,
+					next,
 					"VALIDATE_STRING_PATTERN_EMAIL_CHARS_EXCEPTION",
 					prefixWithLabel("Email contains invalid characters", rules.Label),
 					nil,
 				)
 			} else if !emailRegexp.MatchString(str) {
-				return nil, wwgo.NewClientError(
+				return validationFailed(
+					ctx,
+					next,
 					"VALIDATE_STRING_PATTERN_EMAIL_FORMAT_EXCEPTION",
 					prefixWithLabel("Please enter a valid email address", rules.Label),
 					nil,
@@ -179,10 +191,12 @@ func ValidateStringDirective(ctx context.Context, obj interface{}, next graphql.
 				return nil, errors.Wrap(err, "Invalid RegExp")
 			}
 			if !exp.MatchString(str) {
-				return nil, wwgo.NewClientError(
+				return validationFailed(
+					ctx,
+					next,
 					"VALIDATE_STRING_PATTERN_REGEXP_EXCEPTION",
 					prefixWithLabel("Invalid format", rules.Label),
-					nil,
+					map[string]interface{}{"regExp": *rules.RegExp},
 				)
 			}
 		}
@@ -249,36 +263,44 @@ func ValidateDateDirective(ctx context.Context, obj interface{}, next graphql.Re
 
 	// Validate.
 	if rules.BeforeDate != nil && !date.Before(rules.BeforeDate.Time()) {
-		return nil, wwgo.NewClientError(
+		return validationFailed(
+			ctx,
+			next,
 			"VALIDATE_DATE_BEFORE_DATE_EXCEPTION",
 			prefixWithLabel(fmt.Sprintf("Must be before %s", rules.BeforeDate.Time().Format(scalars.GqlDateFormat)), rules.Label),
-			nil,
+			map[string]interface{}{"date": rules.BeforeDate.String()},
 		)
 	}
 	if rules.BeforeRelative != nil {
 		d := time.Now().AddDate(rules.BeforeRelative.Years, rules.BeforeRelative.Months, rules.BeforeRelative.Days)
 		if !date.Before(d) {
-			return nil, wwgo.NewClientError(
+			return validationFailed(
+				ctx,
+				next,
 				"VALIDATE_DATE_BEFORE_RELATIVE_EXCEPTION",
 				prefixWithLabel(fmt.Sprintf("Must be before %s", d.Format(scalars.GqlDateFormat)), rules.Label),
-				nil,
+				map[string]interface{}{"date": d.Format(scalars.GqlDateFormat)},
 			)
 		}
 	}
 	if rules.AfterDate != nil && !date.After(rules.AfterDate.Time()) {
-		return nil, wwgo.NewClientError(
+		return validationFailed(
+			ctx,
+			next,
 			"VALIDATE_DATE_After_DATE_EXCEPTION",
 			prefixWithLabel(fmt.Sprintf("Must be after %s", rules.AfterDate.Time().Format(scalars.GqlDateFormat)), rules.Label),
-			nil,
+			map[string]interface{}{"date": rules.AfterDate.String()},
 		)
 	}
 	if rules.AfterRelative != nil {
 		d := time.Now().AddDate(rules.AfterRelative.Years, rules.AfterRelative.Months, rules.AfterRelative.Days)
 		if !date.After(d) {
-			return nil, wwgo.NewClientError(
+			return validationFailed(
+				ctx,
+				next,
 				"VALIDATE_DATE_AFTER_RELATIVE_EXCEPTION",
 				prefixWithLabel(fmt.Sprintf("Must be after %s", d.Format(scalars.GqlDateFormat)), rules.Label),
-				nil,
+				map[string]interface{}{"date": d.Format(scalars.GqlDateFormat)},
 			)
 		}
 	}
@@ -374,17 +396,21 @@ func ValidateDecimalDirective(ctx context.Context, obj interface{}, next graphql
 
 	// Validate.
 	if rules.Min != nil && value.LessThan(*rules.Min) {
-		return nil, wwgo.NewClientError(
+		return validationFailed(
+			ctx,
+			next,
 			"VALIDATE_DECIMAL_MIN_EXCEPTION",
 			prefixWithLabel(fmt.Sprintf("Must be at least %s", rules.Min.String()), rules.Label),
-			nil,
+			map[string]interface{}{"min": rules.Min.String()},
 		)
 	}
 	if rules.Max != nil && value.GreaterThan(*rules.Max) {
-		return nil, wwgo.NewClientError(
+		return validationFailed(
+			ctx,
+			next,
 			"VALIDATE_DECIMAL_MAX_EXCEPTION",
 			prefixWithLabel(fmt.Sprintf("Must be no more than %s", rules.Max.String()), rules.Label),
-			nil,
+			map[string]interface{}{"max": rules.Max.String()},
 		)
 	}
 
@@ -470,17 +496,21 @@ func ValidateIntDirective(ctx context.Context, obj interface{}, next graphql.Res
 
 	// Validate.
 	if rules.Min != nil && value < *rules.Min {
-		return nil, wwgo.NewClientError(
+		return validationFailed(
+			ctx,
+			next,
 			"VALIDATE_INT_MIN_EXCEPTION",
 			prefixWithLabel(fmt.Sprintf("Must be at least %d", *rules.Min), rules.Label),
-			nil,
+			map[string]interface{}{"min": *rules.Min},
 		)
 	}
 	if rules.Max != nil && value > *rules.Max {
-		return nil, wwgo.NewClientError(
+		return validationFailed(
+			ctx,
+			next,
 			"VALIDATE_INT_MAX_EXCEPTION",
 			prefixWithLabel(fmt.Sprintf("Must be no more than %d", *rules.Max), rules.Label),
-			nil,
+			map[string]interface{}{"max": *rules.Max},
 		)
 	}
 
@@ -516,23 +546,58 @@ func ValidateArrayDirective(ctx context.Context, obj interface{}, next graphql.R
 
 	// Validate.
 	if rules.MinLength != nil && len(value) < *rules.MinLength {
-		return nil, wwgo.NewClientError(
+		return validationFailed(
+			ctx,
+			next,
 			"VALIDATE_ARRAY_MINLENGTH_EXCEPTION",
 			prefixWithLabel(fmt.Sprintf("Must have at least %d items", *rules.MinLength), rules.Label),
-			nil,
+			map[string]interface{}{"minLength": *rules.MinLength},
 		)
 	}
 	if rules.MaxLength != nil && len(value) > *rules.MaxLength {
-		return nil, wwgo.NewClientError(
+		return validationFailed(
+			ctx,
+			next,
 			"VALIDATE_ARRAY_MAXLENGTH_EXCEPTION",
 			prefixWithLabel(fmt.Sprintf("Must have at most %d items", *rules.MaxLength), rules.Label),
-			nil,
+			map[string]interface{}{"maxLength": *rules.MaxLength},
 		)
 	}
 
 	return next(ctx)
 }
 
+// validationFailed records the violation and carries on if the
+// ValidationCollector extension is in use, so that every violation is
+// reported at once. Otherwise, it fails immediately.
+func validationFailed(ctx context.Context, next graphql.Resolver, code string, message string, params map[string]interface{}) (interface{}, error) {
+	field := wwgo.ClientErrorField{
+		Path:    inputPath(ctx),
+		Code:    code,
+		Message: message,
+		Params:  params,
+	}
+	if collector := validationCollectorFromContext(ctx); collector != nil {
+		collector.add(graphql.GetFieldContext(ctx), field)
+		return next(ctx)
+	}
+	return nil, wwgo.NewClientError(code, message, nil).AddField(field)
+}
+
+// inputPath returns the path of the input field within the arguments i.e.
+// "input.addresses[0].postcode".
+func inputPath(ctx context.Context) string {
+	var path ast.Path
+	for it := graphql.GetPathContext(ctx); it != nil; it = it.Parent {
+		if it.Index != nil {
+			path = append(ast.Path{ast.PathIndex(*it.Index)}, path...)
+		} else if it.Field != nil {
+			path = append(ast.Path{ast.PathName(*it.Field)}, path...)
+		}
+	}
+	return path.String()
+}
+
 func prefixWithLabel(str string, label *string) string {
 	if label != nil && !strings.HasPrefix(str, *label) {
 		return fmt.Sprintf("%s %s", *label, strings.ToLower(str[0:1])+str[1:])
@@ -0,0 +1,88 @@
+package wwgraphql
+
+import (
+	"context"
+	"fmt"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/weavingwebs/wwgo"
+	"sync"
+)
+
+var validationCollectorCtxKey = &contextKey{"validationCollector"}
+
+type contextKey struct {
+	name string
+}
+
+// ValidationCollector is a gqlgen extension that makes the validate
+// directives collect every violation in the arguments of a field, then fail
+// with a single ClientError listing them all (see ClientError.Fields). A
+// single violation keeps its own code, several are a VALIDATION_EXCEPTION. It
+// is opt-in, i.e.
+//
+//	srv := wwgraphql.NewGraphQlServer(es, log, false)
+//	srv.Use(wwgraphql.ValidationCollector{})
+type ValidationCollector struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.FieldInterceptor
+} = ValidationCollector{}
+
+func (ValidationCollector) ExtensionName() string {
+	return "ValidationCollector"
+}
+
+func (ValidationCollector) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (ValidationCollector) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	collector := &validationCollector{
+		violations: map[*graphql.FieldContext][]wwgo.ClientErrorField{},
+	}
+	return next(context.WithValue(ctx, validationCollectorCtxKey, collector))
+}
+
+// InterceptField runs after the arguments have been parsed & before the
+// resolver, so we fail here if any violations were collected.
+func (ValidationCollector) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	if collector := validationCollectorFromContext(ctx); collector != nil {
+		fields := collector.take(graphql.GetFieldContext(ctx))
+		if len(fields) == 1 {
+			return nil, wwgo.NewClientError(fields[0].Code, fields[0].Message, nil).AddField(fields[0])
+		}
+		if len(fields) > 1 {
+			message := fmt.Sprintf("%s (and %d more %s)", fields[0].Message, len(fields)-1, wwgo.Plural(len(fields)-1, "error", "errors"))
+			return nil, wwgo.NewClientFieldsError("VALIDATION_EXCEPTION", message, fields)
+		}
+	}
+	return next(ctx)
+}
+
+type validationCollector struct {
+	// Keyed by the field the arguments belong to, root fields of queries are
+	// resolved concurrently.
+	violations map[*graphql.FieldContext][]wwgo.ClientErrorField
+	mut        sync.Mutex
+}
+
+func validationCollectorFromContext(ctx context.Context) *validationCollector {
+	collector, _ := ctx.Value(validationCollectorCtxKey).(*validationCollector)
+	return collector
+}
+
+func (c *validationCollector) add(fc *graphql.FieldContext, field wwgo.ClientErrorField) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.violations[fc] = append(c.violations[fc], field)
+}
+
+func (c *validationCollector) take(fc *graphql.FieldContext) []wwgo.ClientErrorField {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	fields := c.violations[fc]
+	delete(c.violations, fc)
+	return fields
+}
@@ -0,0 +1,90 @@
+package wwgraphql
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/pkg/errors"
+	"github.com/weavingwebs/wwgo"
+	"testing"
+)
+
+// collectViolations runs the violations through the ValidationCollector for
+// one field, returning the error from InterceptField.
+func collectViolations(t *testing.T, violations []wwgo.ClientErrorField) error {
+	t.Helper()
+	var err error
+	ValidationCollector{}.InterceptOperation(context.Background(), func(ctx context.Context) graphql.ResponseHandler {
+		ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{})
+		for _, v := range violations {
+			if _, err := validationFailed(ctx, passResolver, v.Code, v.Message, v.Params); err != nil {
+				t.Fatalf("expected the violation to be collected, got %v", err)
+			}
+		}
+		resolved := false
+		_, err = ValidationCollector{}.InterceptField(ctx, func(ctx context.Context) (interface{}, error) {
+			resolved = true
+			return nil, nil
+		})
+		if resolved && len(violations) != 0 {
+			t.Fatal("expected the resolver not to run")
+		}
+		return nil
+	})
+	return err
+}
+
+func passResolver(ctx context.Context) (interface{}, error) {
+	return nil, nil
+}
+
+func TestValidationCollectorNoViolations(t *testing.T) {
+	if err := collectViolations(t, nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestValidationCollectorOneViolation(t *testing.T) {
+	err := collectViolations(t, []wwgo.ClientErrorField{
+		{Code: "VALIDATE_STRING_PATTERN_EMAIL_FORMAT_EXCEPTION", Message: "Must be a valid email"},
+	})
+	var clientErr *wwgo.ClientError
+	if !errors.As(err, &clientErr) {
+		t.Fatalf("expected a ClientError, got %v", err)
+	}
+	if clientErr.GqlErrorCode() != "VALIDATE_STRING_PATTERN_EMAIL_FORMAT_EXCEPTION" {
+		t.Fatalf("expected the violation's code, got %s", clientErr.GqlErrorCode())
+	}
+	if clientErr.Error() != "Must be a valid email" || len(clientErr.Fields()) != 1 {
+		t.Fatalf("unexpected error %q %v", clientErr.Error(), clientErr.Fields())
+	}
+}
+
+func TestValidationCollectorManyViolations(t *testing.T) {
+	err := collectViolations(t, []wwgo.ClientErrorField{
+		{Code: "VALIDATE_STRING_PATTERN_EMAIL_FORMAT_EXCEPTION", Message: "Must be a valid email"},
+		{Code: "VALIDATE_STRING_MINLENGTH_EXCEPTION", Message: "Must be at least 8 characters"},
+		{Code: "VALIDATE_ARRAY_MAXLENGTH_EXCEPTION", Message: "Must have at most 3 items"},
+	})
+	var clientErr *wwgo.ClientError
+	if !errors.As(err, &clientErr) {
+		t.Fatalf("expected a ClientError, got %v", err)
+	}
+	if clientErr.GqlErrorCode() != "VALIDATION_EXCEPTION" {
+		t.Fatalf("expected VALIDATION_EXCEPTION, got %s", clientErr.GqlErrorCode())
+	}
+	if clientErr.Error() != "Must be a valid email (and 2 more errors)" {
+		t.Fatalf("unexpected message %q", clientErr.Error())
+	}
+	if len(clientErr.Fields()) != 3 || clientErr.Fields()[1].Code != "VALIDATE_STRING_MINLENGTH_EXCEPTION" {
+		t.Fatalf("expected every violation, got %v", clientErr.Fields())
+	}
+}
+
+func TestValidationWithoutCollector(t *testing.T) {
+	ctx := graphql.WithFieldContext(context.Background(), &graphql.FieldContext{})
+	_, err := validationFailed(ctx, passResolver, "VALIDATE_STRING_MINLENGTH_EXCEPTION", "Must be at least 8 characters", nil)
+	var clientErr *wwgo.ClientError
+	if !errors.As(err, &clientErr) || clientErr.GqlErrorCode() != "VALIDATE_STRING_MINLENGTH_EXCEPTION" {
+		t.Fatalf("expected the violation to fail immediately, got %v", err)
+	}
+}
@@ -17,6 +17,14 @@ type DaemonServer interface {
 	Start(ctx context.Context) error
 }
 
+// DaemonServerFunc adapts a blocking function to a DaemonServer, i.e.
+// wwhttp.DaemonServerFunc(cronTab.Run).
+type DaemonServerFunc func(ctx context.Context) error
+
+func (fn DaemonServerFunc) Start(ctx context.Context) error {
+	return fn(ctx)
+}
+
 // Wait will block until the context is cancelled and will exit/panic or return.
 func (d *Daemon) Wait() {
 	if err := d.gos.Wait(); err != nil {
@@ -48,3 +56,21 @@ func RunDaemon(ctx context.Context, srv DaemonServer) *Daemon {
 	})
 	return d
 }
+
+// DaemonServerGroup runs multiple servers together (i.e. a Server and a
+// wwgo.CronTab via DaemonServerFunc), if any of them stops then the others are
+// stopped too.
+type DaemonServerGroup []DaemonServer
+
+func (g DaemonServerGroup) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	gos := &errgroup.Group{}
+	for _, srv := range g {
+		gos.Go(func() error {
+			defer cancel()
+			return srv.Start(ctx)
+		})
+	}
+	return gos.Wait()
+}
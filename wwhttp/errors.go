@@ -0,0 +1,136 @@
+package wwhttp
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/weavingwebs/wwgo"
+	"maps"
+	"net/http"
+)
+
+// DefaultErrorStatuses maps wwgo.ClientError codes to HTTP statuses, any
+// other ClientError is a 400.
+var DefaultErrorStatuses = map[string]int{
+	"BAD_REQUEST":            http.StatusBadRequest,
+	"UNAUTHENTICATED":        http.StatusUnauthorized,
+	"UNAUTHORIZED":           http.StatusUnauthorized,
+	"FORBIDDEN":              http.StatusForbidden,
+	"NOT_FOUND":              http.StatusNotFound,
+	"CONFLICT":               http.StatusConflict,
+	"VALIDATION_EXCEPTION":   http.StatusUnprocessableEntity,
+	"RATE_LIMITED":           http.StatusTooManyRequests,
+	"PAYLOAD_TOO_LARGE":      http.StatusRequestEntityTooLarge,
+	"UNSUPPORTED_MEDIA_TYPE": http.StatusUnsupportedMediaType,
+}
+
+// Problem is an RFC 7807 problem details response.
+type Problem struct {
+	Type      string                  `json:"type"`
+	Title     string                  `json:"title"`
+	Status    int                     `json:"status"`
+	Detail    string                  `json:"detail,omitempty"`
+	Instance  string                  `json:"instance,omitempty"`
+	Code      string                  `json:"code,omitempty"`
+	Fields    []wwgo.ClientErrorField `json:"fields,omitempty"`
+	RequestId string                  `json:"requestId,omitempty"`
+}
+
+// ErrorHandlerFunc is a http.HandlerFunc that can return an error, see
+// ErrorRenderer.Handler.
+type ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ErrorRenderer writes errors as problem+json responses. Like the GraphQL
+// DefaultErrorPresenter, only wwgo.ClientError messages are shown to the
+// client, anything else is logged & hidden.
+type ErrorRenderer struct {
+	Log zerolog.Logger
+	// Optional, ClientError code to HTTP status (default DefaultErrorStatuses).
+	Statuses map[string]int
+}
+
+func NewErrorRenderer(log zerolog.Logger) *ErrorRenderer {
+	return &ErrorRenderer{
+		Log:      log,
+		Statuses: maps.Clone(DefaultErrorStatuses),
+	}
+}
+
+// Handler adapts a handler that returns an error, rendering the error if
+// there is one. The handler must not have written a response if it errors.
+func (er *ErrorRenderer) Handler(fn ErrorHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := fn(w, r); err != nil {
+			er.Render(w, r, err)
+		}
+	}
+}
+
+// Render logs the error & writes it as a problem+json response.
+func (er *ErrorRenderer) Render(w http.ResponseWriter, r *http.Request, err error) {
+	problem := er.Problem(r, err)
+
+	// Always log all errors, client errors are only warnings.
+	var logEvt *zerolog.Event
+	if problem.Status < http.StatusInternalServerError {
+		logEvt = er.Log.Warn()
+	} else {
+		logEvt = er.Log.Error().Stack()
+	}
+	logEvt.Err(err).Int("status", problem.Status)
+	if problem.RequestId != "" {
+		logEvt.Str("requestID", problem.RequestId)
+	}
+	logEvt.Send()
+
+	// If a client error wrapped an error, log it as well.
+	var clientErr *wwgo.ClientError
+	if errors.As(err, &clientErr) {
+		if wrappedErr := clientErr.Unwrap(); wrappedErr != nil {
+			er.Log.Warn().Stack().Err(wrappedErr).Send()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		er.Log.Err(err).Msg("Failed to write error response")
+	}
+}
+
+// Problem converts the error to a Problem without logging or writing it.
+func (er *ErrorRenderer) Problem(r *http.Request, err error) Problem {
+	problem := Problem{
+		Type:      "about:blank",
+		Instance:  r.URL.Path,
+		RequestId: middleware.GetReqID(r.Context()),
+	}
+
+	var clientErr *wwgo.ClientError
+	if errors.As(err, &clientErr) {
+		problem.Status = er.statusForCode(clientErr.GqlErrorCode())
+		problem.Code = clientErr.GqlErrorCode()
+		// Use the message from the client error directly, it may have been
+		// wrapped by another error that is not client safe.
+		problem.Detail = clientErr.Error()
+		problem.Fields = clientErr.Fields()
+	} else {
+		problem.Status = http.StatusInternalServerError
+		problem.Detail = "An unexpected error occurred, please try again later"
+	}
+	problem.Title = http.StatusText(problem.Status)
+	return problem
+}
+
+func (er *ErrorRenderer) statusForCode(code string) int {
+	statuses := er.Statuses
+	if statuses == nil {
+		statuses = DefaultErrorStatuses
+	}
+	if status, ok := statuses[code]; ok {
+		return status
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,340 @@
+package wwhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/weavingwebs/wwgo"
+	"github.com/weavingwebs/wwgo/wwauth"
+	"github.com/weavingwebs/wwgo/wwdb"
+	"io"
+	"math"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+)
+
+// RateLimitKeyFunc returns the flood identifier for the request, an empty key
+// is not limited.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RateLimitKeyIp keys on IpForContext, so IpContextMiddleware must be used.
+func RateLimitKeyIp(r *http.Request) string {
+	ip := IpForContext(r.Context())
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// RateLimitKeyJwtSubject keys on wwauth.JwtSubjectFromContext, anonymous
+// requests are not limited.
+func RateLimitKeyJwtSubject(r *http.Request) string {
+	return wwauth.JwtSubjectFromContext(r.Context())
+}
+
+// RateLimitKeyJwtSubjectOrIp keys on the JWT subject, falling back to the IP.
+func RateLimitKeyJwtSubjectOrIp(r *http.Request) string {
+	if sub := RateLimitKeyJwtSubject(r); sub != "" {
+		return sub
+	}
+	return RateLimitKeyIp(r)
+}
+
+type RateLimitOpt struct {
+	Log zerolog.Logger
+	// Optional, defaults to RateLimitKeyIp.
+	Key RateLimitKeyFunc
+	// Optional, renders errors as problem+json, otherwise plain text is used.
+	Errors *ErrorRenderer
+	// Optional, allow requests if the flood store fails, otherwise they fail
+	// with a 503 (or a 500 with Errors).
+	FailOpen bool
+}
+
+func (opt RateLimitOpt) key(r *http.Request) string {
+	if opt.Key == nil {
+		return RateLimitKeyIp(r)
+	}
+	return opt.Key(r)
+}
+
+// RateLimitMiddleware limits requests with the flood, i.e.
+//
+//	r.With(wwhttp.RateLimitMiddleware(loginFlood, opt)).Post("/login", login)
+func RateLimitMiddleware(flood *wwdb.Flood, opt RateLimitOpt) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opt.hit(w, r, []*wwdb.Flood{flood}) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// RateLimitByRouteMiddleware applies the flood for the chi route pattern, keyed
+// by "METHOD /pattern" or "/pattern" for any method, i.e.
+//
+//	"POST /login":        {Name: "login", Window: time.Hour, Threshold: 10},
+//	"/password-reset/*":  {Name: "passwordReset", Window: time.Hour, Threshold: 5},
+//
+// Routes without a policy are not limited. It must be used on the top level
+// router.
+func RateLimitByRouteMiddleware(policies map[string]*wwdb.Flood, opt RateLimitOpt) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pattern := routePattern(r)
+			flood, ok := policies[r.Method+" "+pattern]
+			if !ok {
+				flood, ok = policies[pattern]
+			}
+			if !ok || opt.hit(w, r, []*wwdb.Flood{flood}) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// routePattern resolves the route, as middleware runs before chi sets the
+// RoutePattern.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return ""
+	}
+	if rctx.Routes != nil {
+		path := r.URL.RawPath
+		if path == "" {
+			path = r.URL.Path
+		}
+		tctx := chi.NewRouteContext()
+		if rctx.Routes.Match(tctx, r.Method, path) {
+			return tctx.RoutePattern()
+		}
+	}
+	return rctx.RoutePattern()
+}
+
+// graphQlRateLimitMaxBody is how much of the body is read to find the query,
+// larger requests are rejected.
+const graphQlRateLimitMaxBody = 1 << 20
+
+// GraphQlRateLimitMiddleware applies the floods for the operation name & root
+// fields of GraphQL requests, i.e.
+//
+//	"login":         {Name: "login", Window: time.Hour, Threshold: 10},
+//	"resetPassword": {Name: "resetPassword", Window: time.Hour, Threshold: 5},
+//
+// The client chooses the operation name, so prefer the root field names.
+// Queries that can't be parsed are passed on for the server to reject, but
+// bodies that are too large, can't be decoded or have an unsupported content
+// type are rejected so they can't bypass the limits.
+func GraphQlRateLimitMiddleware(policies map[string]*wwdb.Flood, opt RateLimitOpt) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests, status, err := graphQlRequests(r)
+			if err != nil {
+				opt.error(w, r, status, err)
+				return
+			}
+			var floods []*wwdb.Flood
+			for _, req := range requests {
+				for _, name := range graphQlOperationNames(req.Query, req.OperationName) {
+					if flood, ok := policies[name]; ok && !wwgo.SliceIncludes(floods, flood) {
+						floods = append(floods, flood)
+					}
+				}
+			}
+			if len(floods) == 0 || opt.hit(w, r, floods) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+type graphQlRequest struct {
+	Query         string `json:"query"`
+	OperationName string `json:"operationName"`
+}
+
+// graphQlRequests reads the operations from GET, JSON POST & multipart POST
+// (see https://github.com/jaydenseric/graphql-multipart-request-spec)
+// requests, including batches, restoring the body. On error, the status to
+// respond with is returned.
+func graphQlRequests(r *http.Request) ([]graphQlRequest, int, error) {
+	if r.Method == http.MethodGet {
+		return []graphQlRequest{{
+			Query:         r.URL.Query().Get("query"),
+			OperationName: r.URL.Query().Get("operationName"),
+		}}, 0, nil
+	}
+	if r.Method != http.MethodPost || r.Body == nil {
+		return nil, 0, nil
+	}
+
+	// Restore what is read of the body for the server.
+	read := &bytes.Buffer{}
+	body := io.TeeReader(r.Body, read)
+	defer func() {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(read, r.Body), r.Body}
+	}()
+
+	mediaType, mediaParams, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		return decodeGraphQlRequests(body)
+	case "multipart/form-data":
+		// The operations must be the first field.
+		part, err := multipart.NewReader(body, mediaParams["boundary"]).NextPart()
+		if err != nil {
+			return nil, http.StatusBadRequest, wwgo.NewClientError("BAD_REQUEST", "Invalid multipart body", err)
+		}
+		if part.FormName() != "operations" {
+			return nil, http.StatusBadRequest, wwgo.NewClientError("BAD_REQUEST", "The operations field must be first", nil)
+		}
+		return decodeGraphQlRequests(part)
+	}
+	return nil, http.StatusUnsupportedMediaType, wwgo.NewClientError("UNSUPPORTED_MEDIA_TYPE", "Unsupported content type", nil)
+}
+
+// decodeGraphQlRequests decodes a request or a batch of requests, up to
+// graphQlRateLimitMaxBody.
+func decodeGraphQlRequests(body io.Reader) ([]graphQlRequest, int, error) {
+	data, err := io.ReadAll(io.LimitReader(body, graphQlRateLimitMaxBody+1))
+	if err != nil {
+		return nil, http.StatusBadRequest, wwgo.NewClientError("BAD_REQUEST", "Failed to read body", err)
+	}
+	if len(data) > graphQlRateLimitMaxBody {
+		return nil, http.StatusRequestEntityTooLarge, wwgo.NewClientError("PAYLOAD_TOO_LARGE", "Request body is too large", nil)
+	}
+
+	var requests []graphQlRequest
+	data = bytes.TrimSpace(data)
+	if len(data) != 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &requests)
+	} else {
+		requests = make([]graphQlRequest, 1)
+		err = json.Unmarshal(data, &requests[0])
+	}
+	if err != nil {
+		return nil, http.StatusBadRequest, wwgo.NewClientError("BAD_REQUEST", "Invalid JSON body", err)
+	}
+	return requests, 0, nil
+}
+
+// graphQlOperationNames returns the operation name & root field names of the
+// operation that will be executed.
+func graphQlOperationNames(query string, operationName string) []string {
+	if query == "" {
+		return nil
+	}
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, op := range doc.Operations {
+		if operationName != "" && op.Name != operationName {
+			continue
+		}
+		if op.Name != "" {
+			names = append(names, op.Name)
+		}
+		names = append(names, graphQlRootFields(doc, op.SelectionSet, map[string]bool{})...)
+	}
+	return names
+}
+
+func graphQlRootFields(doc *ast.QueryDocument, selections ast.SelectionSet, visited map[string]bool) []string {
+	var names []string
+	for _, sel := range selections {
+		switch s := sel.(type) {
+		case *ast.Field:
+			names = append(names, s.Name)
+		case *ast.InlineFragment:
+			names = append(names, graphQlRootFields(doc, s.SelectionSet, visited)...)
+		case *ast.FragmentSpread:
+			fragment := doc.Fragments.ForName(s.Name)
+			if fragment == nil || visited[s.Name] {
+				continue
+			}
+			visited[s.Name] = true
+			names = append(names, graphQlRootFields(doc, fragment.SelectionSet, visited)...)
+		}
+	}
+	return names
+}
+
+// hit registers a hit with each flood, writing the response & returning false
+// if one of them is not allowed. The floods after it are not hit.
+func (opt RateLimitOpt) hit(w http.ResponseWriter, r *http.Request, floods []*wwdb.Flood) bool {
+	key := opt.key(r)
+	if key == "" {
+		return true
+	}
+
+	for _, flood := range floods {
+		allowed, retryAfter, err := flood.Hit(r.Context(), key)
+		if err != nil {
+			opt.Log.Error().Stack().Err(err).Str("flood", flood.Name).Msg("Rate limit failed")
+			if opt.FailOpen {
+				continue
+			}
+			opt.error(w, r, http.StatusServiceUnavailable, errors.Wrapf(err, "rate limit failed"))
+			return false
+		}
+		if allowed {
+			continue
+		}
+
+		seconds := strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+		setRateLimitPolicy(w, flood)
+		w.Header().Set("RateLimit-Remaining", "0")
+		w.Header().Set("RateLimit-Reset", seconds)
+		w.Header().Set("Retry-After", seconds)
+		opt.Log.Warn().Str("flood", flood.Name).Str("key", key).Msg("Rate limited")
+		opt.error(w, r, http.StatusTooManyRequests, wwgo.NewClientError("RATE_LIMITED", "Too many requests, please try again later", nil))
+		return false
+	}
+
+	// Describe the most restrictive policy.
+	var policy *wwdb.Flood
+	for _, flood := range floods {
+		if policy == nil || flood.Threshold < policy.Threshold {
+			policy = flood
+		}
+	}
+	setRateLimitPolicy(w, policy)
+	count, err := policy.Count(r.Context(), key)
+	if err != nil {
+		opt.Log.Error().Stack().Err(err).Str("flood", policy.Name).Msg("Failed to count rate limit")
+		return true
+	}
+	// The store doesn't return when the oldest hit expires, so the window is
+	// an upper bound.
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(max(policy.Threshold-count, 0)))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(policy.Window.Seconds()))))
+	return true
+}
+
+func setRateLimitPolicy(w http.ResponseWriter, flood *wwdb.Flood) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(flood.Threshold))
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", flood.Threshold, int(flood.Window.Seconds())))
+}
+
+func (opt RateLimitOpt) error(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if opt.Errors != nil {
+		opt.Errors.Render(w, r, err)
+		return
+	}
+	http.Error(w, http.StatusText(status), status)
+}